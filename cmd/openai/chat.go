@@ -0,0 +1,353 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	openaisdk "github.com/sashabaranov/go-openai"
+	"github.com/ysicing/openai/openai"
+)
+
+const chatHelp = `Commands:
+  /system [text]  show or set the system prompt
+  /model [name]   show or switch the model
+  /image path     attach an image file or URL to the next message
+  /reset          start a new conversation with the same system prompt
+  /save [path]    save the conversation as JSONL, by default to the session file
+  /load path      resume a conversation saved with /save, saving to it from then on
+  /usage          show the tokens and cost of the session
+  /help           show this help
+  /exit           leave (or Ctrl-D)
+End a line with \ to continue the message on the next line.`
+
+// chat is an interactive session. Messages are the history sent with every turn.
+type chat struct {
+	opts     options
+	client   *openai.Client
+	usage    *openai.UsageTracker
+	noStream bool
+
+	messages []openaisdk.ChatCompletionMessage
+	// images are attached to the next user message.
+	images []string
+	// path is the session file the conversation is saved to after every turn.
+	path string
+
+	stdout, stderr io.Writer
+}
+
+// runChat executes the chat command and returns the exit status.
+func runChat(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	c := &chat{usage: openai.NewUsageTracker(nil), stdout: stdout, stderr: stderr}
+	fs := flag.NewFlagSet("openai chat", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	c.opts.register(fs)
+	fs.StringVar(&c.path, "session", "", "JSONL session file to resume and save the conversation to")
+	fs.BoolVar(&c.noStream, "no-stream", false, "print each reply once complete instead of streaming it")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: openai chat [flags]")
+		fs.PrintDefaults()
+		fmt.Fprintln(stderr, "\n"+chatHelp)
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return exitUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintln(stderr, "openai: chat takes no arguments")
+		return exitUsage
+	}
+
+	var err error
+	if c.client, err = c.opts.newClient(openai.WithUsageTracker(c.usage)); err != nil {
+		return fail(stderr, err)
+	}
+	c.images = c.opts.images
+	if c.path != "" {
+		if c.messages, err = loadSession(c.path); errors.Is(err, os.ErrNotExist) {
+			err = nil
+		} else if err == nil {
+			fmt.Fprintf(stderr, "Resumed %d messages from %s\n", len(c.messages), c.path)
+		}
+		if err != nil {
+			return fail(stderr, err)
+		}
+	}
+	if c.opts.system != "" {
+		c.setSystem(c.opts.system)
+	}
+
+	fmt.Fprintf(stderr, "Chatting with %s. Type /help for commands.\n", c.model())
+	scanner := bufio.NewScanner(stdin)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16<<20)
+	var input strings.Builder
+	for {
+		if input.Len() == 0 {
+			fmt.Fprint(stderr, "> ")
+		} else {
+			fmt.Fprint(stderr, ". ")
+		}
+		if !scanner.Scan() {
+			break
+		}
+		line := scanner.Text()
+		if strings.HasSuffix(line, `\`) {
+			input.WriteString(strings.TrimSuffix(line, `\`) + "\n")
+			continue
+		}
+		input.WriteString(line)
+		text := strings.TrimSpace(input.String())
+		input.Reset()
+
+		switch {
+		case text == "":
+		case strings.HasPrefix(text, "/"):
+			if c.command(text) {
+				return 0
+			}
+		default:
+			c.send(ctx, text)
+		}
+	}
+	fmt.Fprintln(stderr)
+	if err := scanner.Err(); err != nil {
+		return fail(stderr, err)
+	}
+	return 0
+}
+
+// send adds the user message to the conversation and prints the reply. The message is
+// dropped again if the request fails, so that it can be retried.
+func (c *chat) send(ctx context.Context, text string) {
+	msg, err := userMessage(text, c.images)
+	if err != nil {
+		fmt.Fprintf(c.stderr, "openai: %v\n", err)
+		return
+	}
+	c.images = nil
+	c.messages = append(c.messages, msg)
+
+	// Ctrl-C cancels the reply rather than the session.
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	var reply string
+	if c.noStream {
+		var resp openaisdk.ChatCompletionResponse
+		resp, err = c.client.CreateChatCompletionWithMessage(ctx, c.messages)
+		if err == nil && len(resp.Choices) == 0 {
+			err = openai.ErrEmptyResponse
+		}
+		if err == nil {
+			reply = resp.Choices[0].Message.Content
+			fmt.Fprintln(c.stdout, reply)
+		}
+	} else {
+		var resp *openai.Response
+		if resp, err = stream(ctx, c.client, c.messages, c.stdout); err == nil {
+			reply = resp.Content
+		}
+	}
+	if err != nil {
+		c.messages = c.messages[:len(c.messages)-1]
+		fmt.Fprintf(c.stderr, "openai: %v\n", err)
+		return
+	}
+
+	c.messages = append(c.messages, openaisdk.ChatCompletionMessage{Role: openaisdk.ChatMessageRoleAssistant, Content: reply})
+	c.autosave()
+}
+
+// command runs a slash command and reports whether the session ends.
+func (c *chat) command(line string) bool {
+	name, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+
+	switch name {
+	case "/exit", "/quit":
+		return true
+	case "/help":
+		fmt.Fprintln(c.stderr, chatHelp)
+	case "/system":
+		if arg == "" {
+			fmt.Fprintf(c.stderr, "System prompt: %q\n", c.system())
+			return false
+		}
+		c.setSystem(arg)
+		c.autosave()
+	case "/model":
+		if arg == "" {
+			fmt.Fprintf(c.stderr, "Model: %s\n", c.model())
+			return false
+		}
+		opts := c.opts
+		opts.model = arg
+		client, err := opts.newClient(openai.WithUsageTracker(c.usage))
+		if err != nil {
+			fmt.Fprintf(c.stderr, "openai: %v\n", err)
+			return false
+		}
+		c.opts, c.client = opts, client
+		fmt.Fprintf(c.stderr, "Switched to %s\n", arg)
+	case "/image":
+		if arg == "" {
+			fmt.Fprintln(c.stderr, "Usage: /image path")
+			return false
+		}
+		if _, err := imageURL(arg); err != nil {
+			fmt.Fprintf(c.stderr, "openai: %v\n", err)
+			return false
+		}
+		c.images = append(c.images, arg)
+		fmt.Fprintf(c.stderr, "Attached %s to the next message\n", arg)
+	case "/reset":
+		system := c.system()
+		c.messages, c.images = nil, nil
+		if system != "" {
+			c.setSystem(system)
+		}
+		// The saved conversation is kept; the new one is saved with /save.
+		c.path = ""
+		fmt.Fprintln(c.stderr, "Conversation cleared")
+	case "/save":
+		path := arg
+		if path == "" {
+			path = c.path
+		}
+		if path == "" {
+			fmt.Fprintln(c.stderr, "Usage: /save path")
+			return false
+		}
+		if err := saveSession(path, c.messages); err != nil {
+			fmt.Fprintf(c.stderr, "openai: %v\n", err)
+			return false
+		}
+		fmt.Fprintf(c.stderr, "Saved %d messages to %s\n", len(c.messages), path)
+	case "/load":
+		if arg == "" {
+			fmt.Fprintln(c.stderr, "Usage: /load path")
+			return false
+		}
+		messages, err := loadSession(arg)
+		if err != nil {
+			fmt.Fprintf(c.stderr, "openai: %v\n", err)
+			return false
+		}
+		c.messages, c.path = messages, arg
+		fmt.Fprintf(c.stderr, "Loaded %d messages from %s\n", len(messages), arg)
+	case "/usage":
+		total := c.usage.Snapshot().Total
+		fmt.Fprintf(c.stderr, "Requests: %d, prompt tokens: %d (%d cached), completion tokens: %d, total: %d, cost: $%.4f\n",
+			total.Requests, total.PromptTokens, total.CachedTokens, total.CompletionTokens, total.TotalTokens, total.Cost)
+		if total.Unpriced > 0 {
+			fmt.Fprintf(c.stderr, "The cost leaves out %d requests to models without a price\n", total.Unpriced)
+		}
+	default:
+		fmt.Fprintf(c.stderr, "Unknown command %s; type /help for commands\n", name)
+	}
+	return false
+}
+
+// model returns the model the next message is sent to.
+func (c *chat) model() string {
+	opts, err := c.opts.clientOptions()
+	if err != nil {
+		return ""
+	}
+	for _, s := range openai.Settings(opts...) {
+		if s.Name == "model" {
+			return s.Value
+		}
+	}
+	return ""
+}
+
+// system returns the system prompt of the conversation.
+func (c *chat) system() string {
+	if len(c.messages) > 0 && c.messages[0].Role == openaisdk.ChatMessageRoleSystem {
+		return c.messages[0].Content
+	}
+	return ""
+}
+
+// setSystem replaces the system prompt of the conversation.
+func (c *chat) setSystem(text string) {
+	msg := openaisdk.ChatCompletionMessage{Role: openaisdk.ChatMessageRoleSystem, Content: text}
+	if c.system() != "" {
+		c.messages[0] = msg
+		return
+	}
+	c.messages = append([]openaisdk.ChatCompletionMessage{msg}, c.messages...)
+}
+
+// autosave writes the conversation to the session file, if any.
+func (c *chat) autosave() {
+	if c.path == "" {
+		return
+	}
+	if err := saveSession(c.path, c.messages); err != nil {
+		fmt.Fprintf(c.stderr, "openai: %v\n", err)
+	}
+}
+
+// saveSession writes the messages to path as JSON Lines, one message per line.
+// The file is replaced atomically so that an interrupted save keeps the previous session.
+func saveSession(path string, messages []openaisdk.ChatCompletionMessage) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, msg := range messages {
+		if err := enc.Encode(msg); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// loadSession reads a session written by saveSession.
+func loadSession(path string) ([]openaisdk.ChatCompletionMessage, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var messages []openaisdk.ChatCompletionMessage
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64<<20)
+	for n := 1; scanner.Scan(); n++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var msg openaisdk.ChatCompletionMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+		messages = append(messages, msg)
+	}
+	return messages, scanner.Err()
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	openaisdk "github.com/sashabaranov/go-openai"
+	"github.com/ysicing/openai/openai/openaitest"
+)
+
+func TestRunChat(t *testing.T) {
+	srv := newServer(t)
+	srv.Reply(openaitest.Text("Hi there."), openaitest.Text("Blue."), openaitest.RateLimited, openaitest.Text("Hello again."))
+	dir := t.TempDir()
+	path := filepath.Join(dir, "session.jsonl")
+	image := filepath.Join(dir, "dot.gif")
+	if err := os.WriteFile(image, []byte("GIF89a\x01\x00\x01\x00"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	input := strings.Join([]string{
+		"/system Be brief.",
+		"Hello",
+		"/image " + image,
+		"What colour is\\",
+		"this?",
+		"Rate limited",
+		"/usage",
+		"/save " + path,
+		"/reset",
+		"/model gpt-4o",
+		"Hello",
+		"/load " + path,
+		"/exit",
+		"ignored",
+	}, "\n")
+
+	var stdout, stderr bytes.Buffer
+	if code := runChat(context.Background(), nil, strings.NewReader(input), &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit status 0, got %d: %s", code, stderr.String())
+	}
+	if stdout.String() != "Hi there.\nBlue.\nHello again.\n" {
+		t.Errorf("Expected the streamed replies, got %q", stdout.String())
+	}
+	for _, expected := range []string{"rate limited", "Requests: 2", "Saved 5 messages", "Switched to gpt-4o", "Loaded 5 messages"} {
+		if !strings.Contains(stderr.String(), expected) {
+			t.Errorf("Expected %q in the session output, got %s", expected, stderr.String())
+		}
+	}
+
+	reqs := srv.ChatRequests()
+	if len(reqs) != 4 {
+		t.Fatalf("Expected 4 requests, got %d", len(reqs))
+	}
+	// The second turn carries the history, the multi-line message and the image.
+	second := reqs[1].Messages
+	if len(second) != 4 || second[0].Content != "Be brief." || second[2].Content != "Hi there." {
+		t.Errorf("Expected the system prompt and the first turn, got %+v", second)
+	}
+	if parts := second[3].MultiContent; len(parts) != 2 || parts[0].Text != "What colour is\nthis?" ||
+		!strings.HasPrefix(parts[1].ImageURL.URL, "data:image/gif;base64,") {
+		t.Errorf("Expected the multi-line message with the image, got %+v", parts)
+	}
+	// The failed turn is dropped from the history.
+	if n := len(reqs[2].Messages); n != 6 {
+		t.Errorf("Expected 6 messages in the failed turn, got %d", n)
+	}
+	// After /reset only the system prompt remains, and /model switched the model.
+	if last := reqs[3]; len(last.Messages) != 2 || last.Messages[0].Role != openaisdk.ChatMessageRoleSystem || last.Model != "gpt-4o" {
+		t.Errorf("Expected a reset conversation with gpt-4o, got %+v", last)
+	}
+}
+
+func TestRunChat_Session(t *testing.T) {
+	srv := newServer(t)
+	srv.Reply(openaitest.Text("Nice to meet you, Ada."), openaitest.Text("Your name is Ada."))
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"--session", path, "--no-stream"}
+	if code := runChat(context.Background(), args, strings.NewReader("My name is Ada.\n"), &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit status 0, got %d: %s", code, stderr.String())
+	}
+	messages, err := loadSession(path)
+	if err != nil || len(messages) != 2 || messages[1].Content != "Nice to meet you, Ada." {
+		t.Fatalf("Expected the saved turn, got %+v (%v)", messages, err)
+	}
+
+	// A new session resumes from the file.
+	if code := runChat(context.Background(), args, strings.NewReader("What is my name?\n"), &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit status 0, got %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "Resumed 2 messages") {
+		t.Errorf("Expected the session to resume, got %s", stderr.String())
+	}
+	if reqs := srv.ChatRequests(); len(reqs[1].Messages) != 3 || reqs[1].Stream {
+		t.Errorf("Expected the resumed history in a blocking request, got %+v", reqs[1])
+	}
+	if messages, _ := loadSession(path); len(messages) != 4 {
+		t.Errorf("Expected 4 saved messages, got %d", len(messages))
+	}
+
+	if err := os.WriteFile(path, []byte("{not json}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if code := runChat(context.Background(), args, strings.NewReader(""), &stdout, &stderr); code != exitError {
+		t.Errorf("Expected exit status %d for a corrupt session, got %d", exitError, code)
+	}
+}
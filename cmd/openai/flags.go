@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	openaisdk "github.com/sashabaranov/go-openai"
+	"github.com/ysicing/openai/openai"
+)
+
+// options holds the flags shared by the commands.
+type options struct {
+	system  string
+	model   string
+	baseURL string
+	profile string
+	images  stringList
+}
+
+// register adds the flags to fs.
+func (o *options) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.system, "system", "", "system prompt")
+	fs.StringVar(&o.model, "model", "", "model, overriding the profile and OPENAI_MODEL")
+	fs.StringVar(&o.baseURL, "base-url", "", "API base URL, overriding the profile and OPENAI_BASE_URL")
+	fs.StringVar(&o.profile, "profile", "", "profile of the config file (see OPENAI_CONFIG) instead of the environment")
+	fs.Var(&o.images, "image", "image file or URL to attach; may be repeated")
+}
+
+// clientOptions returns the options of the profile or the environment followed by those of the flags.
+func (o *options) clientOptions() ([]openai.Option, error) {
+	opts := []openai.Option{openai.WithEnv()}
+	if o.profile != "" {
+		path, err := openai.DefaultConfigPath()
+		if err != nil {
+			return nil, err
+		}
+		cfg, err := openai.LoadConfig(path)
+		if err != nil {
+			return nil, err
+		}
+		p, err := cfg.Profile(o.profile)
+		if err != nil {
+			return nil, err
+		}
+		opts = p.Options()
+	}
+	if o.model != "" {
+		opts = append(opts, openai.WithModel(o.model))
+	}
+	if o.baseURL != "" {
+		opts = append(opts, openai.WithBaseURL(o.baseURL))
+	}
+	return opts, nil
+}
+
+// newClient creates the client from the profile or the environment, applying the flags
+// and then the extra options.
+func (o *options) newClient(extra ...openai.Option) (*openai.Client, error) {
+	opts, err := o.clientOptions()
+	if err != nil {
+		return nil, err
+	}
+	return openai.New(append(opts, extra...)...)
+}
+
+// userMessage returns the user message of a prompt with the images attached.
+func userMessage(prompt string, images []string) (openaisdk.ChatCompletionMessage, error) {
+	msg := openaisdk.ChatCompletionMessage{Role: openaisdk.ChatMessageRoleUser}
+	if len(images) == 0 {
+		msg.Content = prompt
+		return msg, nil
+	}
+
+	msg.MultiContent = []openaisdk.ChatMessagePart{{Type: openaisdk.ChatMessagePartTypeText, Text: prompt}}
+	for _, image := range images {
+		url, err := imageURL(image)
+		if err != nil {
+			return msg, err
+		}
+		msg.MultiContent = append(msg.MultiContent, openaisdk.ChatMessagePart{
+			Type:     openaisdk.ChatMessagePartTypeImageURL,
+			ImageURL: &openaisdk.ChatMessageImageURL{URL: url},
+		})
+	}
+	return msg, nil
+}
+
+// imageURL returns the URL of an image argument: URLs are passed on as they are,
+// files are sent inline as data URLs.
+func imageURL(image string) (string, error) {
+	for _, scheme := range []string{"http://", "https://", "data:"} {
+		if strings.HasPrefix(image, scheme) {
+			return image, nil
+		}
+	}
+	data, err := os.ReadFile(image)
+	if err != nil {
+		return "", err
+	}
+	mimeType := http.DetectContentType(data)
+	if !strings.HasPrefix(mimeType, "image/") {
+		return "", fmt.Errorf("%s is not an image (%s)", image, mimeType)
+	}
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// stringList is a flag that may be repeated.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(val string) error {
+	if val == "" {
+		return errors.New("empty value")
+	}
+	*l = append(*l, val)
+	return nil
+}
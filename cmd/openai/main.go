@@ -0,0 +1,53 @@
+// Command openai sends a prompt to a chat model and prints the reply.
+//
+// Usage:
+//
+//	openai [flags] [prompt ...]
+//	openai chat [flags]
+//
+// The prompt is taken from the arguments, from standard input, or from both, in which
+// case the input follows the arguments, e.g. git diff | openai "Write a commit message".
+// Flags come before the prompt. The reply is streamed to standard output unless --json
+// is given, which prints the reply with its token usage as a JSON object.
+//
+// The client is configured from the environment (OPENAI_API_KEY, OPENAI_BASE_URL, ...)
+// or from a profile of the config file with --profile; --model and --base-url override both.
+//
+// The chat command starts an interactive session that keeps the conversation, streams
+// the replies and accepts slash commands, e.g. /model, /image and /usage; type /help for
+// the list. With --session the conversation is saved to a JSON Lines file after every
+// turn and resumed from it on the next start.
+//
+// On failure the error is printed to standard error and the exit status tells its class:
+//
+//	1  other errors
+//	2  invalid usage
+//	3  authentication failed
+//	4  rate limited
+//	5  quota exhausted
+//	6  context length exceeded
+//	7  content filtered
+//	8  model not found
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/signal"
+)
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "chat" {
+		os.Exit(runChat(context.Background(), os.Args[2:], os.Stdin, os.Stdout, os.Stderr))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	var stdin io.Reader
+	if fi, err := os.Stdin.Stat(); err == nil && fi.Mode()&os.ModeCharDevice == 0 {
+		stdin = os.Stdin
+	}
+	code := run(ctx, os.Args[1:], stdin, os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	openaisdk "github.com/sashabaranov/go-openai"
+	"github.com/ysicing/openai/openai"
+)
+
+// Exit statuses.
+const (
+	exitError = 1
+	exitUsage = 2
+)
+
+// exitCodes are the exit statuses of the typed errors, see the command documentation.
+var exitCodes = []struct {
+	err  error
+	code int
+}{
+	{openai.ErrAuth, 3},
+	{openai.ErrRateLimited, 4},
+	{openai.ErrQuotaExhausted, 5},
+	{openai.ErrContextLengthExceeded, 6},
+	{openai.ErrContentFiltered, 7},
+	{openai.ErrModelNotFound, 8},
+}
+
+// output is the --json result.
+type output struct {
+	Model        string          `json:"model"`
+	Provider     string          `json:"provider"`
+	Content      string          `json:"content"`
+	FinishReason string          `json:"finish_reason"`
+	Usage        openaisdk.Usage `json:"usage"`
+}
+
+// errorOutput is the --json result of a failure.
+type errorOutput struct {
+	Error struct {
+		Kind       string `json:"kind,omitempty"`
+		Message    string `json:"message"`
+		StatusCode int    `json:"status_code,omitempty"`
+		Code       string `json:"code,omitempty"`
+		RequestID  string `json:"request_id,omitempty"`
+	} `json:"error"`
+}
+
+// run executes the command line and returns the exit status. stdin is nil when
+// standard input is a terminal.
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	var (
+		o       options
+		jsonOut bool
+	)
+	fs := flag.NewFlagSet("openai", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	o.register(fs)
+	fs.BoolVar(&jsonOut, "json", false, "print the reply and its usage as JSON instead of streaming it")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: openai [flags] [prompt ...]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return exitUsage
+	}
+
+	prompt := strings.Join(fs.Args(), " ")
+	if stdin != nil {
+		input, err := io.ReadAll(stdin)
+		if err != nil {
+			return fail(stderr, err)
+		}
+		if in := strings.TrimSpace(string(input)); in != "" && prompt != "" {
+			prompt += "\n\n" + in
+		} else if in != "" {
+			prompt = in
+		}
+	}
+	if prompt == "" {
+		fmt.Fprintln(stderr, "openai: no prompt; pass it as arguments or on standard input")
+		return exitUsage
+	}
+
+	client, err := o.newClient()
+	if err != nil {
+		return fail(stderr, err)
+	}
+	var messages []openaisdk.ChatCompletionMessage
+	if o.system != "" {
+		messages = append(messages, openaisdk.ChatCompletionMessage{Role: openaisdk.ChatMessageRoleSystem, Content: o.system})
+	}
+	msg, err := userMessage(prompt, o.images)
+	if err != nil {
+		return fail(stderr, err)
+	}
+	messages = append(messages, msg)
+
+	if jsonOut {
+		return complete(ctx, client, messages, stdout, stderr)
+	}
+	if _, err := stream(ctx, client, messages, stdout); err != nil {
+		return fail(stderr, err)
+	}
+	return 0
+}
+
+// complete prints the reply to the messages as JSON.
+func complete(ctx context.Context, client *openai.Client, messages []openaisdk.ChatCompletionMessage, stdout, stderr io.Writer) int {
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+
+	resp, err := client.CreateChatCompletionWithMessage(ctx, messages)
+	if err == nil && len(resp.Choices) == 0 {
+		err = openai.ErrEmptyResponse
+	}
+	if err != nil {
+		_ = enc.Encode(newErrorOutput(err))
+		return fail(stderr, err)
+	}
+
+	_ = enc.Encode(output{
+		Model:        resp.Model,
+		Provider:     client.Name(),
+		Content:      resp.Choices[0].Message.Content,
+		FinishReason: string(resp.Choices[0].FinishReason),
+		Usage:        resp.Usage,
+	})
+	return 0
+}
+
+// stream prints the reply to the messages as it arrives and returns it once complete.
+func stream(ctx context.Context, client *openai.Client, messages []openaisdk.ChatCompletionMessage, w io.Writer) (*openai.Response, error) {
+	s, err := client.ChatStream(ctx, messages)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+
+	var last string
+	for delta, err := range s.Deltas() {
+		if err != nil {
+			if last != "" {
+				fmt.Fprintln(w)
+			}
+			return nil, err
+		}
+		if delta.Content != "" {
+			fmt.Fprint(w, delta.Content)
+			last = delta.Content
+		}
+	}
+	if last != "" && !strings.HasSuffix(last, "\n") {
+		fmt.Fprintln(w)
+	}
+	return s.Response()
+}
+
+// fail prints the error and returns the exit status of its class.
+func fail(stderr io.Writer, err error) int {
+	fmt.Fprintf(stderr, "openai: %v\n", err)
+	return exitCode(err)
+}
+
+// exitCode returns the exit status of an error.
+func exitCode(err error) int {
+	for _, e := range exitCodes {
+		if errors.Is(err, e.err) {
+			return e.code
+		}
+	}
+	return exitError
+}
+
+// newErrorOutput describes an error for --json, with the details of an *openai.Error.
+func newErrorOutput(err error) errorOutput {
+	var out errorOutput
+	out.Error.Message = err.Error()
+	var e *openai.Error
+	if errors.As(err, &e) {
+		if e.Kind != nil {
+			out.Error.Kind = e.Kind.Error()
+		}
+		out.Error.Message = e.Message
+		out.Error.StatusCode = e.StatusCode
+		out.Error.Code = e.Code
+		out.Error.RequestID = e.RequestID
+	}
+	return out
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	openaisdk "github.com/sashabaranov/go-openai"
+	"github.com/ysicing/openai/openai/openaitest"
+)
+
+// newServer starts a fake API and points the environment of the command at it.
+func newServer(t *testing.T) *openaitest.Server {
+	t.Helper()
+	srv := openaitest.NewServer(t)
+	t.Setenv("OPENAI_API_KEY", openaitest.Token)
+	t.Setenv("OPENAI_BASE_URL", srv.URL+"/v1")
+	t.Setenv("OPENAI_MODEL", "")
+	t.Setenv("HTTPS_PROXY", "")
+	t.Setenv("ALL_PROXY", "")
+	return srv
+}
+
+func TestRun_Stream(t *testing.T) {
+	srv := newServer(t)
+	srv.Reply(openaitest.Text("The capital is Paris."))
+
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), []string{"--system", "Be brief.", "--model", "gpt-4o", "Capital", "of", "France?"},
+		nil, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("Expected exit status 0, got %d: %s", code, stderr.String())
+	}
+	if stdout.String() != "The capital is Paris.\n" {
+		t.Errorf("Expected the streamed reply, got %q", stdout.String())
+	}
+
+	req := srv.ChatRequests()[0]
+	if !req.Stream || req.Model != "gpt-4o" {
+		t.Errorf("Expected a streamed request for gpt-4o, got %+v", req)
+	}
+	if req.Messages[0].Content != "Be brief." || req.Messages[1].Content != "Capital of France?" {
+		t.Errorf("Expected the system prompt and the arguments, got %+v", req.Messages)
+	}
+}
+
+func TestRun_Stdin(t *testing.T) {
+	srv := newServer(t)
+
+	var stdout, stderr bytes.Buffer
+	if code := run(context.Background(), []string{"Summarize:"}, strings.NewReader("a long text\n"), &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit status 0, got %d: %s", code, stderr.String())
+	}
+	if got := srv.ChatRequests()[0].Messages[0].Content; got != "Summarize:\n\na long text" {
+		t.Errorf("Expected the arguments followed by the input, got %q", got)
+	}
+
+	if code := run(context.Background(), nil, strings.NewReader(" \n"), &stdout, &stderr); code != exitUsage {
+		t.Errorf("Expected exit status %d without a prompt, got %d", exitUsage, code)
+	}
+}
+
+func TestRun_JSON(t *testing.T) {
+	srv := newServer(t)
+	srv.Reply(openaitest.Reply{
+		Content: "Paris",
+		Usage:   &openaisdk.Usage{PromptTokens: 7, CompletionTokens: 1, TotalTokens: 8},
+	})
+
+	var stdout, stderr bytes.Buffer
+	if code := run(context.Background(), []string{"--json", "Capital of France?"}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit status 0, got %d: %s", code, stderr.String())
+	}
+	var out output
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		t.Fatalf("Expected JSON output, got %q: %v", stdout.String(), err)
+	}
+	if out.Content != "Paris" || out.FinishReason != "stop" || out.Usage.TotalTokens != 8 || out.Model == "" {
+		t.Errorf("Expected the reply and its usage, got %+v", out)
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply openaitest.Reply
+		json  bool
+		code  int
+	}{
+		{"Rate limited", openaitest.RateLimited, false, 4},
+		{"Auth", openaitest.Error(http.StatusUnauthorized, "invalid_api_key", "Incorrect API key provided"), false, 3},
+		{"Content filter", openaitest.ContentFiltered, true, 7},
+		{"Server error", openaitest.InternalError, false, exitError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newServer(t)
+			srv.Reply(tt.reply)
+
+			args := []string{"hi"}
+			if tt.json {
+				args = append([]string{"--json"}, args...)
+			}
+			var stdout, stderr bytes.Buffer
+			if code := run(context.Background(), args, nil, &stdout, &stderr); code != tt.code {
+				t.Errorf("Expected exit status %d, got %d", tt.code, code)
+			}
+			if !strings.HasPrefix(stderr.String(), "openai: ") {
+				t.Errorf("Expected the error on stderr, got %q", stderr.String())
+			}
+			if tt.json && !strings.Contains(stdout.String(), `"code": "content_filter"`) {
+				t.Errorf("Expected a JSON error, got %q", stdout.String())
+			}
+		})
+	}
+
+	var stderr bytes.Buffer
+	if code := run(context.Background(), []string{"--unknown"}, nil, &stderr, &stderr); code != exitUsage {
+		t.Errorf("Expected exit status %d for an unknown flag, got %d", exitUsage, code)
+	}
+}
+
+func TestRun_Image(t *testing.T) {
+	srv := newServer(t)
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	path := filepath.Join(t.TempDir(), "chart.png")
+	if err := os.WriteFile(path, png, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"--image", path, "--image", "https://example.com/cat.jpg", "Describe"}
+	if code := run(context.Background(), args, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit status 0, got %d: %s", code, stderr.String())
+	}
+	parts := srv.ChatRequests()[0].Messages[0].MultiContent
+	if len(parts) != 3 || parts[0].Text != "Describe" {
+		t.Fatalf("Expected the prompt and two images, got %+v", parts)
+	}
+	if !strings.HasPrefix(parts[1].ImageURL.URL, "data:image/png;base64,") || parts[2].ImageURL.URL != "https://example.com/cat.jpg" {
+		t.Errorf("Expected an inline file and a URL, got %s and %s", parts[1].ImageURL.URL, parts[2].ImageURL.URL)
+	}
+
+	if code := run(context.Background(), []string{"--image", "run.go", "Describe"}, nil, &stdout, &stderr); code != exitError {
+		t.Errorf("Expected exit status %d for a file that is not an image, got %d", exitError, code)
+	}
+}
@@ -57,7 +57,6 @@ func main() {
 	// 示例 3: 使用本地 Ollama + LLaVA 进行图像理解
 	fmt.Println("\n=== Ollama + LLaVA (本地) ===")
 	ollamaClient, err := openai.New(
-		openai.WithToken("ollama"),
 		openai.WithProvider(openai.Ollama),
 		openai.WithModel("llava:latest"), // LLaVA 模型
 	)
@@ -0,0 +1,489 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+const (
+	// defaultAnthropicURL is the address of the Anthropic API.
+	defaultAnthropicURL = "https://api.anthropic.com"
+	// anthropicVersion is the version of the Messages API the backend speaks.
+	anthropicVersion = "2023-06-01"
+	// defaultAnthropicMaxTokens is sent when the request sets no limit, as the API requires one.
+	defaultAnthropicMaxTokens = 4096
+)
+
+var errAnthropicEmbeddings = errors.New("anthropic: embeddings are not supported")
+
+// anthropicBackend speaks the Anthropic Messages API.
+type anthropicBackend struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+type anthropicContent struct {
+	Type string `json:"type"`
+	Text string `json:"text,omitempty"`
+	// Thinking is the content of a thinking block.
+	Thinking string `json:"thinking,omitempty"`
+
+	Source *anthropicImageSource `json:"source,omitempty"`
+
+	// ID, Name and Input describe a tool_use block.
+	ID    string          `json:"id,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+
+	// ToolUseID and Content describe a tool_result block.
+	ToolUseID string `json:"tool_use_id,omitempty"`
+	Content   string `json:"content,omitempty"`
+}
+
+type anthropicImageSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+type anthropicMessage struct {
+	Role    string             `json:"role"`
+	Content []anthropicContent `json:"content"`
+}
+
+type anthropicTool struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	InputSchema any    `json:"input_schema"`
+}
+
+type anthropicRequest struct {
+	Model         string             `json:"model"`
+	System        string             `json:"system,omitempty"`
+	Messages      []anthropicMessage `json:"messages"`
+	MaxTokens     int                `json:"max_tokens"`
+	Temperature   *float32           `json:"temperature,omitempty"`
+	TopP          *float32           `json:"top_p,omitempty"`
+	StopSequences []string           `json:"stop_sequences,omitempty"`
+	Tools         []anthropicTool    `json:"tools,omitempty"`
+	ToolChoice    map[string]string  `json:"tool_choice,omitempty"`
+	Stream        bool               `json:"stream,omitempty"`
+}
+
+type anthropicUsage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+}
+
+type anthropicResponse struct {
+	ID         string             `json:"id"`
+	Model      string             `json:"model"`
+	Content    []anthropicContent `json:"content"`
+	StopReason string             `json:"stop_reason"`
+	Usage      anthropicUsage     `json:"usage"`
+}
+
+func (b *anthropicBackend) CreateChatCompletion(
+	ctx context.Context,
+	req openai.ChatCompletionRequest,
+) (openai.ChatCompletionResponse, error) {
+	resp, err := b.post(ctx, req, false)
+	if err != nil {
+		return openai.ChatCompletionResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	var r anthropicResponse
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return openai.ChatCompletionResponse{}, fmt.Errorf("anthropic: invalid response: %w", err)
+	}
+
+	msg := openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant}
+	for _, c := range r.Content {
+		switch c.Type {
+		case "text":
+			msg.Content += c.Text
+		case "thinking":
+			msg.ReasoningContent += c.Thinking
+		case "tool_use":
+			msg.ToolCalls = append(msg.ToolCalls, openai.ToolCall{
+				ID:       c.ID,
+				Type:     openai.ToolTypeFunction,
+				Function: openai.FunctionCall{Name: c.Name, Arguments: string(c.Input)},
+			})
+		}
+	}
+
+	return openai.ChatCompletionResponse{
+		ID:     r.ID,
+		Object: "chat.completion",
+		Model:  r.Model,
+		Choices: []openai.ChatCompletionChoice{{
+			Message:      msg,
+			FinishReason: anthropicFinishReason(r.StopReason),
+		}},
+		Usage: r.Usage.openai(),
+	}, nil
+}
+
+func (b *anthropicBackend) CreateChatCompletionStream(
+	ctx context.Context,
+	req openai.ChatCompletionRequest,
+) (ChatCompletionStream, error) {
+	resp, err := b.post(ctx, req, true)
+	if err != nil {
+		return nil, err
+	}
+	return &anthropicStream{body: resp.Body, events: newSSEReader(resp.Body)}, nil
+}
+
+func (b *anthropicBackend) CreateEmbeddings(
+	context.Context,
+	openai.EmbeddingRequestConverter,
+) (openai.EmbeddingResponse, error) {
+	return openai.EmbeddingResponse{}, errAnthropicEmbeddings
+}
+
+func (b *anthropicBackend) post(ctx context.Context, req openai.ChatCompletionRequest, stream bool) (*http.Response, error) {
+	r, err := anthropicChatRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	r.Stream = stream
+	body, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return postJSON(ctx, b.client, b.url("/v1/messages"), b.header(), body, parseAnthropicError)
+}
+
+// ListModels returns the models available to the API key, following the pages of the list.
+func (b *anthropicBackend) ListModels(ctx context.Context) (openai.ModelsList, error) {
+	var list openai.ModelsList
+	query := url.Values{"limit": {"1000"}}
+	for {
+		var page struct {
+			Data []struct {
+				ID        string    `json:"id"`
+				CreatedAt time.Time `json:"created_at"`
+			} `json:"data"`
+			HasMore bool   `json:"has_more"`
+			LastID  string `json:"last_id"`
+		}
+		if err := getJSON(ctx, b.client, b.url("/v1/models?"+query.Encode()), b.header(), &page, parseAnthropicError); err != nil {
+			return openai.ModelsList{}, fmt.Errorf("anthropic: %w", err)
+		}
+		for _, m := range page.Data {
+			list.Models = append(list.Models, openai.Model{
+				ID:        m.ID,
+				Object:    "model",
+				CreatedAt: m.CreatedAt.Unix(),
+				OwnedBy:   Anthropic,
+			})
+		}
+		if !page.HasMore || page.LastID == "" {
+			return list, nil
+		}
+		query.Set("after_id", page.LastID)
+	}
+}
+
+func (b *anthropicBackend) url(path string) string {
+	return strings.TrimSuffix(b.baseURL, "/") + path
+}
+
+func (b *anthropicBackend) header() http.Header {
+	header := http.Header{"Anthropic-Version": {anthropicVersion}}
+	if b.token != "" {
+		header.Set("X-Api-Key", b.token)
+	}
+	return header
+}
+
+// anthropicChatRequest translates an OpenAI chat completion request to the Messages API.
+// System messages become the system prompt, tool results become user messages and
+// consecutive messages of the same role are merged, as the API requires alternating roles.
+func anthropicChatRequest(req openai.ChatCompletionRequest) (anthropicRequest, error) {
+	r := anthropicRequest{
+		Model:         req.Model,
+		MaxTokens:     req.MaxCompletionTokens,
+		StopSequences: req.Stop,
+	}
+	if r.MaxTokens == 0 {
+		r.MaxTokens = req.MaxTokens
+	}
+	if r.MaxTokens == 0 {
+		r.MaxTokens = defaultAnthropicMaxTokens
+	}
+
+	// Recent models reject temperature and top_p together; top_p wins when it narrows sampling.
+	if req.TopP > 0 && req.TopP < 1 {
+		r.TopP = &req.TopP
+	} else {
+		temperature := min(req.Temperature, 1)
+		r.Temperature = &temperature
+	}
+
+	for _, tool := range req.Tools {
+		if tool.Function == nil {
+			continue
+		}
+		schema := tool.Function.Parameters
+		if schema == nil {
+			schema = map[string]any{"type": "object"}
+		}
+		r.Tools = append(r.Tools, anthropicTool{
+			Name:        tool.Function.Name,
+			Description: tool.Function.Description,
+			InputSchema: schema,
+		})
+	}
+	if choice, ok := req.ToolChoice.(string); ok && len(r.Tools) > 0 {
+		switch choice {
+		case "auto":
+			r.ToolChoice = map[string]string{"type": "auto"}
+		case "required":
+			r.ToolChoice = map[string]string{"type": "any"}
+		case "none":
+			r.ToolChoice = map[string]string{"type": "none"}
+		}
+	}
+
+	var system []string
+	for _, m := range req.Messages {
+		role := openai.ChatMessageRoleUser
+		var content []anthropicContent
+
+		switch m.Role {
+		case openai.ChatMessageRoleSystem, openai.ChatMessageRoleDeveloper:
+			system = append(system, m.Content)
+			continue
+		case openai.ChatMessageRoleTool:
+			content = append(content, anthropicContent{Type: "tool_result", ToolUseID: m.ToolCallID, Content: m.Content})
+		case openai.ChatMessageRoleAssistant:
+			role = openai.ChatMessageRoleAssistant
+			if m.Content != "" {
+				content = append(content, anthropicContent{Type: "text", Text: m.Content})
+			}
+			for _, call := range m.ToolCalls {
+				input := json.RawMessage(call.Function.Arguments)
+				if !json.Valid(input) {
+					input = json.RawMessage("{}")
+				}
+				content = append(content, anthropicContent{Type: "tool_use", ID: call.ID, Name: call.Function.Name, Input: input})
+			}
+		default:
+			if m.Content != "" {
+				content = append(content, anthropicContent{Type: "text", Text: m.Content})
+			}
+			for _, part := range m.MultiContent {
+				switch part.Type {
+				case openai.ChatMessagePartTypeText:
+					content = append(content, anthropicContent{Type: "text", Text: part.Text})
+				case openai.ChatMessagePartTypeImageURL:
+					if part.ImageURL == nil {
+						continue
+					}
+					source, err := anthropicImage(part.ImageURL.URL)
+					if err != nil {
+						return r, err
+					}
+					content = append(content, anthropicContent{Type: "image", Source: source})
+				}
+			}
+		}
+
+		if len(content) == 0 {
+			continue
+		}
+		if n := len(r.Messages); n > 0 && r.Messages[n-1].Role == role {
+			r.Messages[n-1].Content = append(r.Messages[n-1].Content, content...)
+			continue
+		}
+		r.Messages = append(r.Messages, anthropicMessage{Role: role, Content: content})
+	}
+	r.System = strings.Join(system, "\n\n")
+	return r, nil
+}
+
+// anthropicImage converts an image URL to an image source: data URLs are sent inline.
+func anthropicImage(rawURL string) (*anthropicImageSource, error) {
+	rest, ok := strings.CutPrefix(rawURL, "data:")
+	if !ok {
+		return &anthropicImageSource{Type: "url", URL: rawURL}, nil
+	}
+	meta, data, ok := strings.Cut(rest, ",")
+	mediaType, isBase64 := strings.CutSuffix(meta, ";base64")
+	if !ok || !isBase64 {
+		return nil, errors.New("anthropic: images must be base64 data URLs or remote URLs")
+	}
+	return &anthropicImageSource{Type: "base64", MediaType: mediaType, Data: data}, nil
+}
+
+func parseAnthropicError(status int, body []byte) error {
+	var e struct {
+		Error struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &e) != nil || e.Error.Message == "" {
+		e.Error.Message = strings.TrimSpace(string(body))
+	}
+	return &openai.APIError{
+		HTTPStatusCode: status,
+		Code:           e.Error.Type,
+		Message:        e.Error.Message,
+		Type:           e.Error.Type,
+	}
+}
+
+func (u anthropicUsage) openai() openai.Usage {
+	prompt := u.InputTokens + u.CacheReadInputTokens + u.CacheCreationInputTokens
+	usage := openai.Usage{
+		PromptTokens:     prompt,
+		CompletionTokens: u.OutputTokens,
+		TotalTokens:      prompt + u.OutputTokens,
+	}
+	if u.CacheReadInputTokens > 0 {
+		usage.PromptTokensDetails = &openai.PromptTokensDetails{CachedTokens: u.CacheReadInputTokens}
+	}
+	return usage
+}
+
+func anthropicFinishReason(reason string) openai.FinishReason {
+	switch reason {
+	case "max_tokens":
+		return openai.FinishReasonLength
+	case "tool_use":
+		return openai.FinishReasonToolCalls
+	case "refusal":
+		return openai.FinishReasonContentFilter
+	}
+	return openai.FinishReasonStop
+}
+
+// anthropicStream converts the events of a streamed message into chunks.
+type anthropicStream struct {
+	body   io.ReadCloser
+	events *sseReader
+
+	id    string
+	model string
+	usage anthropicUsage
+	// tools maps content block indices to tool call indices.
+	tools map[int]int
+}
+
+func (s *anthropicStream) Recv() (openai.ChatCompletionStreamResponse, error) {
+	for {
+		event, data, err := s.events.next()
+		if err != nil {
+			return openai.ChatCompletionStreamResponse{}, err
+		}
+
+		var e struct {
+			Type    string            `json:"type"`
+			Index   int               `json:"index"`
+			Message anthropicResponse `json:"message"`
+			Block   anthropicContent  `json:"content_block"`
+			Delta   struct {
+				Type        string `json:"type"`
+				Text        string `json:"text"`
+				Thinking    string `json:"thinking"`
+				PartialJSON string `json:"partial_json"`
+				StopReason  string `json:"stop_reason"`
+			} `json:"delta"`
+			Usage anthropicUsage `json:"usage"`
+			Error struct {
+				Type    string `json:"type"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(data, &e); err != nil {
+			return openai.ChatCompletionStreamResponse{}, fmt.Errorf("anthropic: invalid stream event %s: %w", event, err)
+		}
+
+		var delta openai.ChatCompletionStreamChoiceDelta
+		var finish openai.FinishReason
+		var usage *openai.Usage
+
+		switch e.Type {
+		case "message_start":
+			s.id, s.model, s.usage = e.Message.ID, e.Message.Model, e.Message.Usage
+			delta.Role = openai.ChatMessageRoleAssistant
+		case "content_block_start":
+			if e.Block.Type != "tool_use" {
+				continue
+			}
+			if s.tools == nil {
+				s.tools = make(map[int]int)
+			}
+			index := len(s.tools)
+			s.tools[e.Index] = index
+			delta.ToolCalls = []openai.ToolCall{{
+				Index:    &index,
+				ID:       e.Block.ID,
+				Type:     openai.ToolTypeFunction,
+				Function: openai.FunctionCall{Name: e.Block.Name},
+			}}
+		case "content_block_delta":
+			switch e.Delta.Type {
+			case "text_delta":
+				delta.Content = e.Delta.Text
+			case "thinking_delta":
+				delta.ReasoningContent = e.Delta.Thinking
+			case "input_json_delta":
+				index := s.tools[e.Index]
+				delta.ToolCalls = []openai.ToolCall{{
+					Index:    &index,
+					Function: openai.FunctionCall{Arguments: e.Delta.PartialJSON},
+				}}
+			default:
+				continue
+			}
+		case "message_delta":
+			s.usage.OutputTokens = e.Usage.OutputTokens
+			finish = anthropicFinishReason(e.Delta.StopReason)
+			u := s.usage.openai()
+			usage = &u
+		case "message_stop":
+			return openai.ChatCompletionStreamResponse{}, io.EOF
+		case "error":
+			return openai.ChatCompletionStreamResponse{}, &openai.APIError{
+				Code:    e.Error.Type,
+				Message: e.Error.Message,
+				Type:    e.Error.Type,
+			}
+		default:
+			// ping and content_block_stop carry nothing to forward.
+			continue
+		}
+
+		return openai.ChatCompletionStreamResponse{
+			ID:      s.id,
+			Object:  "chat.completion.chunk",
+			Model:   s.model,
+			Choices: []openai.ChatCompletionStreamChoice{{Delta: delta, FinishReason: finish}},
+			Usage:   usage,
+		}, nil
+	}
+}
+
+func (s *anthropicStream) Close() error {
+	return s.body.Close()
+}
@@ -0,0 +1,311 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	openaisdk "github.com/sashabaranov/go-openai"
+)
+
+func TestNew_Anthropic(t *testing.T) {
+	if _, err := New(WithProvider(Anthropic), WithToken("sk-ant")); !errors.Is(err, errorsMissingModel) {
+		t.Errorf("Expected missing model error, got: %v", err)
+	}
+	if _, err := New(WithProvider(Anthropic), WithModel("claude-sonnet-4-5")); !errors.Is(err, errorsMissingToken) {
+		t.Errorf("Expected missing token error, got: %v", err)
+	}
+
+	cfg := &config{provider: Anthropic}
+	if got := cfg.endpoint(); got != defaultAnthropicURL {
+		t.Errorf("Expected endpoint %s, got %s", defaultAnthropicURL, got)
+	}
+	if got := providerName(cfg); got != Anthropic {
+		t.Errorf("Expected provider name %s, got %s", Anthropic, got)
+	}
+}
+
+func TestAnthropicChatRequest(t *testing.T) {
+	r, err := anthropicChatRequest(openaisdk.ChatCompletionRequest{
+		Model:       "claude-sonnet-4-5",
+		Temperature: 1.5,
+		Stop:        []string{"END"},
+		Messages: []openaisdk.ChatCompletionMessage{
+			{Role: openaisdk.ChatMessageRoleSystem, Content: "Be brief."},
+			{Role: openaisdk.ChatMessageRoleUser, MultiContent: []openaisdk.ChatMessagePart{
+				{Type: openaisdk.ChatMessagePartTypeText, Text: "Compare"},
+				{Type: openaisdk.ChatMessagePartTypeImageURL, ImageURL: &openaisdk.ChatMessageImageURL{URL: "data:image/png;base64,iVBORw0KGgo="}},
+				{Type: openaisdk.ChatMessagePartTypeImageURL, ImageURL: &openaisdk.ChatMessageImageURL{URL: "https://example.com/cat.jpg"}},
+			}},
+			{Role: openaisdk.ChatMessageRoleAssistant, ToolCalls: []openaisdk.ToolCall{{
+				ID:       "toolu_1",
+				Type:     openaisdk.ToolTypeFunction,
+				Function: openaisdk.FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`},
+			}}},
+			{Role: openaisdk.ChatMessageRoleTool, ToolCallID: "toolu_1", Content: "sunny"},
+			{Role: openaisdk.ChatMessageRoleUser, Content: "And tomorrow?"},
+		},
+		Tools: []openaisdk.Tool{{
+			Type:     openaisdk.ToolTypeFunction,
+			Function: &openaisdk.FunctionDefinition{Name: "get_weather", Parameters: json.RawMessage(`{"type":"object"}`)},
+		}},
+		ToolChoice: "required",
+	})
+	if err != nil {
+		t.Fatalf("Failed to build request: %v", err)
+	}
+
+	if r.System != "Be brief." || r.MaxTokens != defaultAnthropicMaxTokens || r.StopSequences[0] != "END" {
+		t.Errorf("Expected system prompt, default max tokens and stop sequences, got %+v", r)
+	}
+	if r.Temperature == nil || *r.Temperature != 1 || r.TopP != nil {
+		t.Errorf("Expected temperature clamped to 1, got %v", r.Temperature)
+	}
+	if r.ToolChoice["type"] != "any" || r.Tools[0].Name != "get_weather" {
+		t.Errorf("Expected translated tools, got %+v %v", r.Tools, r.ToolChoice)
+	}
+
+	// The tool result and the next user message are merged into one user turn.
+	if len(r.Messages) != 3 {
+		t.Fatalf("Expected 3 alternating messages, got %+v", r.Messages)
+	}
+	images := r.Messages[0].Content
+	if images[1].Source.Type != "base64" || images[1].Source.MediaType != "image/png" || images[1].Source.Data != "iVBORw0KGgo=" {
+		t.Errorf("Expected inline image, got %+v", images[1].Source)
+	}
+	if images[2].Source.Type != "url" || images[2].Source.URL != "https://example.com/cat.jpg" {
+		t.Errorf("Expected URL image, got %+v", images[2].Source)
+	}
+	if call := r.Messages[1].Content[0]; call.Type != "tool_use" || string(call.Input) != `{"city":"Paris"}` {
+		t.Errorf("Expected tool_use block, got %+v", call)
+	}
+	if result := r.Messages[2].Content; result[0].Type != "tool_result" || result[0].ToolUseID != "toolu_1" || result[1].Text != "And tomorrow?" {
+		t.Errorf("Expected tool_result followed by text, got %+v", result)
+	}
+
+	if _, err := anthropicImage("data:image/png,raw"); err == nil {
+		t.Error("Expected error for a non-base64 data URL, got nil")
+	}
+}
+
+func TestClient_Anthropic(t *testing.T) {
+	var (
+		header http.Header
+		sent   anthropicRequest
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("Expected /v1/messages, got %s", r.URL.Path)
+		}
+		header = r.Header
+		_ = json.NewDecoder(r.Body).Decode(&sent)
+		_, _ = io.WriteString(w, `{
+			"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4-5",
+			"content":[{"type":"thinking","thinking":"Hmm."},{"type":"text","text":"Paris"}],
+			"stop_reason":"end_turn",
+			"usage":{"input_tokens":10,"output_tokens":2,"cache_read_input_tokens":90}
+		}`)
+	}))
+	defer srv.Close()
+
+	client, err := New(WithProvider(Anthropic), WithBaseURL(srv.URL), WithToken("sk-ant"), WithModel("claude-sonnet-4-5"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Completion(context.Background(), "Be brief.", "Capital of France?")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if resp.Content != "Paris" || resp.FinishReason != openaisdk.FinishReasonStop {
+		t.Errorf("Expected translated response, got %+v", resp)
+	}
+	if resp.Usage.PromptTokens != 100 || resp.Usage.TotalTokens != 102 || resp.Usage.PromptTokensDetails.CachedTokens != 90 {
+		t.Errorf("Expected cached tokens counted as prompt tokens, got %+v", resp.Usage)
+	}
+	if header.Get("X-Api-Key") != "sk-ant" || header.Get("Anthropic-Version") != anthropicVersion || header.Get("Authorization") != "" {
+		t.Errorf("Expected x-api-key and anthropic-version headers, got %v", header)
+	}
+	if sent.System != "Be brief." || sent.Messages[0].Content[0].Text != "Capital of France?" {
+		t.Errorf("Expected system prompt and user message, got %+v", sent)
+	}
+
+	b := &anthropicBackend{baseURL: srv.URL, client: srv.Client()}
+	raw, err := b.CreateChatCompletion(context.Background(), openaisdk.ChatCompletionRequest{Model: "claude-sonnet-4-5"})
+	if err != nil || raw.Choices[0].Message.ReasoningContent != "Hmm." || raw.ID != "msg_1" {
+		t.Errorf("Expected thinking as reasoning content, got %+v (%v)", raw, err)
+	}
+
+	if _, err := client.Embed(context.Background(), []string{"a"}); !errors.Is(err, errAnthropicEmbeddings) {
+		t.Errorf("Expected embeddings to be unsupported, got: %v", err)
+	}
+}
+
+func TestClient_AnthropicStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, event := range []string{
+			`event: message_start
+data: {"type":"message_start","message":{"id":"msg_1","model":"claude-sonnet-4-5","usage":{"input_tokens":12,"output_tokens":1}}}`,
+			`event: ping
+data: {"type":"ping"}`,
+			`event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+			`event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Let me check"}}`,
+			`event: content_block_start
+data: {"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{}}}`,
+			`event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"city\":"}}`,
+			`event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"Paris\"}"}}`,
+			`event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":20}}`,
+			`event: message_stop
+data: {"type":"message_stop"}`,
+		} {
+			_, _ = io.WriteString(w, event+"\n\n")
+		}
+	}))
+	defer srv.Close()
+
+	client, err := New(WithProvider(Anthropic), WithBaseURL(srv.URL), WithToken("sk-ant"), WithModel("claude-sonnet-4-5"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	stream, err := client.CompletionStream(context.Background(), "", "Weather in Paris?")
+	if err != nil {
+		t.Fatalf("CompletionStream failed: %v", err)
+	}
+	defer stream.Close()
+
+	resp, err := stream.Response()
+	if err != nil {
+		t.Fatalf("Response failed: %v", err)
+	}
+	if resp.Content != "Let me check" || resp.FinishReason != openaisdk.FinishReasonToolCalls {
+		t.Errorf("Expected assembled content and tool_calls, got %+v", resp)
+	}
+	if resp.Usage.PromptTokens != 12 || resp.Usage.CompletionTokens != 20 {
+		t.Errorf("Expected stream usage, got %+v", resp.Usage)
+	}
+
+	// Tool calls are forwarded as indexed deltas, as OpenAI streams them.
+	b := &anthropicBackend{baseURL: srv.URL, client: srv.Client()}
+	s, err := b.CreateChatCompletionStream(context.Background(), openaisdk.ChatCompletionRequest{Model: "claude-sonnet-4-5"})
+	if err != nil {
+		t.Fatalf("CreateChatCompletionStream failed: %v", err)
+	}
+	defer s.Close()
+
+	var call openaisdk.ToolCall
+	for {
+		chunk, err := s.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Unexpected stream error: %v", err)
+		}
+		for _, tc := range chunk.Choices[0].Delta.ToolCalls {
+			if *tc.Index != 0 {
+				t.Errorf("Expected tool call index 0, got %d", *tc.Index)
+			}
+			if tc.ID != "" {
+				call.ID, call.Function.Name = tc.ID, tc.Function.Name
+			}
+			call.Function.Arguments += tc.Function.Arguments
+		}
+	}
+	if call.ID != "toolu_1" || call.Function.Name != "get_weather" || call.Function.Arguments != `{"city":"Paris"}` {
+		t.Errorf("Expected assembled tool call, got %+v", call)
+	}
+}
+
+func TestClient_AnthropicError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = io.WriteString(w, `{"type":"error","error":{"type":"rate_limit_error","message":"Number of requests has exceeded your rate limit"}}`)
+	}))
+	defer srv.Close()
+
+	client, err := New(WithProvider(Anthropic), WithBaseURL(srv.URL), WithToken("sk-ant"), WithModel("claude-sonnet-4-5"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	_, err = client.Completion(context.Background(), "", "hi")
+	var apiErr *openaisdk.APIError
+	if !errors.As(err, &apiErr) || apiErr.HTTPStatusCode != http.StatusTooManyRequests || apiErr.Type != "rate_limit_error" {
+		t.Errorf("Expected a 429 API error, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "rate limit") {
+		t.Errorf("Expected the API message, got: %v", err)
+	}
+}
+
+func TestClient_AnthropicCompleteInto(t *testing.T) {
+	var sent anthropicRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&sent)
+		_, _ = io.WriteString(w, `{
+			"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4-5",
+			"content":[{"type":"text","text":"{\"label\":\"positive\",\"score\":0.9}"}],
+			"stop_reason":"end_turn",
+			"usage":{"input_tokens":10,"output_tokens":8}
+		}`)
+	}))
+	defer srv.Close()
+
+	client, err := New(WithProvider(Anthropic), WithBaseURL(srv.URL), WithToken("sk-ant"), WithModel("claude-sonnet-4-5"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	type sentiment struct {
+		Label string  `json:"label" enum:"positive,negative,neutral"`
+		Score float64 `json:"score"`
+	}
+	got, err := CompleteInto[sentiment](context.Background(), client, "Classify sentiment.", "I love it")
+	if err != nil {
+		t.Fatalf("CompleteInto failed: %v", err)
+	}
+	if got.Label != "positive" || got.Score != 0.9 {
+		t.Errorf("Expected {positive 0.9}, got %+v", got)
+	}
+
+	// The Messages API has no response_format, so the schema must reach Claude in the prompt.
+	if !strings.HasPrefix(sent.System, "Classify sentiment.") || !strings.Contains(sent.System, `"label"`) {
+		t.Errorf("Expected the schema in the system prompt, got %q", sent.System)
+	}
+}
+
+func TestClient_AnthropicListModels(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" || r.Header.Get("X-Api-Key") != "sk-ant" {
+			t.Errorf("Expected an authenticated /v1/models request, got %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("after_id") == "" {
+			_, _ = io.WriteString(w, `{"data":[{"id":"claude-sonnet-4-5","created_at":"2025-09-29T00:00:00Z"}],"has_more":true,"last_id":"claude-sonnet-4-5"}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"data":[{"id":"claude-haiku-4-5","created_at":"2025-10-15T00:00:00Z"}],"has_more":false}`)
+	}))
+	defer srv.Close()
+
+	client, err := New(WithProvider(Anthropic), WithBaseURL(srv.URL), WithToken("sk-ant"), WithModel("claude-sonnet-4-5"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	models, err := client.ListModels(context.Background())
+	if err != nil || len(models) != 2 || models[1].ID != "claude-haiku-4-5" {
+		t.Errorf("Expected both pages of models, got %+v (%v)", models, err)
+	}
+}
@@ -0,0 +1,213 @@
+package openai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// maxInlineImage bounds the size of a remote image downloaded for an API that only accepts inline images.
+const maxInlineImage = 20 << 20
+
+// Backend is the wire protocol a Client speaks. New selects one per provider: OpenAI-compatible
+// APIs are served by go-openai (see NewOpenAIBackend) while Ollama, Anthropic and Gemini have
+// native implementations. Custom backends are plugged in with NewWithBackend.
+//
+// Requests and responses use the go-openai types. Implementations should report API failures
+// as *openai.APIError so that retries, fallback and error classification keep working.
+type Backend interface {
+	CreateChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error)
+	CreateChatCompletionStream(ctx context.Context, req openai.ChatCompletionRequest) (ChatCompletionStream, error)
+	CreateEmbeddings(ctx context.Context, req openai.EmbeddingRequestConverter) (openai.EmbeddingResponse, error)
+	ListModels(ctx context.Context) (openai.ModelsList, error)
+}
+
+// ChatCompletionStream yields the chunks of a streamed chat completion until io.EOF.
+type ChatCompletionStream interface {
+	Recv() (openai.ChatCompletionStreamResponse, error)
+	Close() error
+}
+
+// NewOpenAIBackend returns the Backend of an OpenAI-compatible API served by a go-openai client,
+// e.g. to wrap it in a custom Backend passed to NewWithBackend.
+func NewOpenAIBackend(client *openai.Client) Backend {
+	return openaiBackend{client}
+}
+
+// openaiBackend is the backend of OpenAI-compatible APIs, including Azure OpenAI.
+type openaiBackend struct {
+	*openai.Client
+}
+
+func (b openaiBackend) CreateChatCompletionStream(
+	ctx context.Context,
+	req openai.ChatCompletionRequest,
+) (ChatCompletionStream, error) {
+	s, err := b.Client.CreateChatCompletionStream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// postJSON sends a JSON body and returns the response of a successful request.
+// Error responses are read and converted by parseError, which should return an *openai.APIError.
+func postJSON(
+	ctx context.Context,
+	client *http.Client,
+	url string,
+	header http.Header,
+	body []byte,
+	parseError func(status int, body []byte) error,
+) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header = header.Clone()
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < http.StatusBadRequest {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxPeekBody))
+	return nil, parseError(resp.StatusCode, data)
+}
+
+// getJSON decodes the JSON response of a GET request into v.
+// Error responses are read and converted by parseError, as in postJSON.
+func getJSON(
+	ctx context.Context,
+	client *http.Client,
+	url string,
+	header http.Header,
+	v any,
+	parseError func(status int, body []byte) error,
+) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header = header.Clone()
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxPeekBody))
+		return parseError(resp.StatusCode, data)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
+
+// sseReader decodes the server-sent events of a streamed response.
+type sseReader struct {
+	scanner *bufio.Scanner
+}
+
+func newSSEReader(r io.Reader) *sseReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), 16<<20)
+	return &sseReader{scanner: scanner}
+}
+
+// next returns the name and data of the next event, or io.EOF at the end of the stream.
+func (r *sseReader) next() (string, []byte, error) {
+	var (
+		event   string
+		data    []byte
+		hasData bool
+	)
+	for r.scanner.Scan() {
+		line := r.scanner.Text()
+		if line == "" {
+			if hasData {
+				return event, data, nil
+			}
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			if hasData {
+				data = append(data, '\n')
+			}
+			data = append(data, value...)
+			hasData = true
+		}
+	}
+	if err := r.scanner.Err(); err != nil {
+		return "", nil, err
+	}
+	if hasData {
+		return event, data, nil
+	}
+	return "", nil, io.EOF
+}
+
+// inlineImage returns the MIME type and base64 data of an image URL. Remote images are
+// downloaded with client, which must not carry the credentials of the API.
+func inlineImage(ctx context.Context, client *http.Client, rawURL string) (mimeType, data string, err error) {
+	if rest, ok := strings.CutPrefix(rawURL, "data:"); ok {
+		meta, data, ok := strings.Cut(rest, ",")
+		if !ok {
+			return "", "", errors.New("invalid data URL")
+		}
+		mimeType, _ = strings.CutSuffix(meta, ";base64")
+		return mimeType, data, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", "", fmt.Errorf("image download failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("image download failed: %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxInlineImage+1))
+	if err != nil {
+		return "", "", fmt.Errorf("image download failed: %w", err)
+	}
+	if len(body) > maxInlineImage {
+		return "", "", fmt.Errorf("image larger than %d bytes", maxInlineImage)
+	}
+	mimeType, _, _ = strings.Cut(resp.Header.Get("Content-Type"), ";")
+	if !strings.HasPrefix(mimeType, "image/") {
+		mimeType = http.DetectContentType(body)
+	}
+	return mimeType, base64.StdEncoding.EncodeToString(body), nil
+}
@@ -0,0 +1,140 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	openaisdk "github.com/sashabaranov/go-openai"
+)
+
+// fakeBackend answers every chat with a fixed reply, streamed in two chunks.
+type fakeBackend struct {
+	reply    string
+	models   []string
+	requests []openaisdk.ChatCompletionRequest
+}
+
+func (b *fakeBackend) CreateChatCompletion(
+	_ context.Context,
+	req openaisdk.ChatCompletionRequest,
+) (openaisdk.ChatCompletionResponse, error) {
+	b.requests = append(b.requests, req)
+	return openaisdk.ChatCompletionResponse{
+		Model: req.Model,
+		Choices: []openaisdk.ChatCompletionChoice{{
+			Message:      openaisdk.ChatCompletionMessage{Role: openaisdk.ChatMessageRoleAssistant, Content: b.reply},
+			FinishReason: openaisdk.FinishReasonStop,
+		}},
+		Usage: openaisdk.Usage{PromptTokens: 3, CompletionTokens: 2, TotalTokens: 5},
+	}, nil
+}
+
+func (b *fakeBackend) CreateChatCompletionStream(
+	_ context.Context,
+	req openaisdk.ChatCompletionRequest,
+) (ChatCompletionStream, error) {
+	b.requests = append(b.requests, req)
+	return &fakeStream{chunks: []string{b.reply[:2], b.reply[2:]}}, nil
+}
+
+func (b *fakeBackend) CreateEmbeddings(
+	context.Context,
+	openaisdk.EmbeddingRequestConverter,
+) (openaisdk.EmbeddingResponse, error) {
+	return openaisdk.EmbeddingResponse{}, errors.New("not implemented")
+}
+
+func (b *fakeBackend) ListModels(context.Context) (openaisdk.ModelsList, error) {
+	var list openaisdk.ModelsList
+	for _, id := range b.models {
+		list.Models = append(list.Models, openaisdk.Model{ID: id})
+	}
+	return list, nil
+}
+
+type fakeStream struct {
+	chunks []string
+}
+
+func (s *fakeStream) Recv() (openaisdk.ChatCompletionStreamResponse, error) {
+	if len(s.chunks) == 0 {
+		return openaisdk.ChatCompletionStreamResponse{}, io.EOF
+	}
+	chunk := openaisdk.ChatCompletionStreamResponse{
+		Choices: []openaisdk.ChatCompletionStreamChoice{{Delta: openaisdk.ChatCompletionStreamChoiceDelta{Content: s.chunks[0]}}},
+	}
+	s.chunks = s.chunks[1:]
+	if len(s.chunks) == 0 {
+		chunk.Choices[0].FinishReason = openaisdk.FinishReasonStop
+	}
+	return chunk, nil
+}
+
+func (s *fakeStream) Close() error {
+	return nil
+}
+
+func TestNewWithBackend(t *testing.T) {
+	if _, err := NewWithBackend(nil); err == nil {
+		t.Error("Expected error for a nil backend, got nil")
+	}
+
+	b := &fakeBackend{reply: "Hello", models: []string{"in-house-1", "in-house-2"}}
+	tracker := NewUsageTracker(nil)
+	client, err := NewWithBackend(b, WithName("in-house"), WithUsageTracker(tracker))
+	if err != nil {
+		t.Fatalf("Expected no token to be required, got: %v", err)
+	}
+
+	resp, err := client.Completion(context.Background(), "", "hi")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if resp.Content != "Hello" || resp.Provider != "in-house" {
+		t.Errorf("Expected the backend reply, got %+v", resp)
+	}
+	if b.requests[0].Model != defaultModel {
+		t.Errorf("Expected default model %s, got %s", defaultModel, b.requests[0].Model)
+	}
+	if total := tracker.Snapshot().Total; total.Requests != 1 || total.TotalTokens != 5 {
+		t.Errorf("Expected tracked usage, got %+v", total)
+	}
+
+	stream, err := client.CompletionStream(context.Background(), "", "hi")
+	if err != nil {
+		t.Fatalf("CompletionStream failed: %v", err)
+	}
+	defer stream.Close()
+	if sresp, err := stream.Response(); err != nil || sresp.Content != "Hello" {
+		t.Errorf("Expected streamed reply, got %+v (%v)", sresp, err)
+	}
+
+	models, err := client.ListModels(context.Background())
+	if err != nil || len(models) != 2 || models[1].ID != "in-house-2" {
+		t.Errorf("Expected the backend models, got %+v (%v)", models, err)
+	}
+}
+
+func TestClient_ListModels(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" {
+			t.Errorf("Expected /v1/models, got %s", r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`{"object":"list","data":[{"id":"gpt-4o","object":"model","owned_by":"openai"}]}`))
+	}))
+	defer srv.Close()
+
+	client, err := New(WithToken("test-token"), WithBaseURL(srv.URL+"/v1"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	models, err := client.ListModels(context.Background())
+	if err != nil || len(models) != 1 || models[0].ID != "gpt-4o" {
+		t.Errorf("Expected gpt-4o, got %+v (%v)", models, err)
+	}
+}
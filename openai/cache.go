@@ -0,0 +1,252 @@
+package openai
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+const (
+	// defaultCacheMaxTemperature is the highest temperature whose responses are cached.
+	defaultCacheMaxTemperature = 0.2
+
+	// cacheHeader marks chat completion responses served from the cache.
+	cacheHeader = "X-Cache"
+	cacheHit    = "HIT"
+)
+
+// Cache stores chat completion responses by request hash.
+// Implementations must be safe for concurrent use. Cache errors never fail a request:
+// a failed Get is treated as a miss and a failed Set is ignored.
+type Cache interface {
+	// Get returns the value stored under key, reporting false when it is missing or expired.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores the value under key.
+	Set(ctx context.Context, key string, value []byte) error
+}
+
+// cacheKey hashes the request together with the client name, since the same model
+// name may be served by different providers. encoding/json sorts map keys, so equal
+// requests always produce the same key.
+func cacheKey(name string, req openai.ChatCompletionRequest) (string, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	h.Write([]byte(name))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// cacheable reports whether the response to req may be served from the cache.
+// Sampling above the temperature threshold is meant to vary between calls.
+func (c *Client) cacheable(req openai.ChatCompletionRequest) bool {
+	return c.cache != nil && !req.Stream && req.Temperature <= c.cacheMaxTemperature
+}
+
+// cachedChatCompletion looks up the response to req. The returned key is empty
+// when the request bypasses the cache.
+func (c *Client) cachedChatCompletion(
+	ctx context.Context,
+	req openai.ChatCompletionRequest,
+) (openai.ChatCompletionResponse, string, bool) {
+	var resp openai.ChatCompletionResponse
+	if !c.cacheable(req) {
+		return resp, "", false
+	}
+	key, err := cacheKey(c.name, req)
+	if err != nil {
+		return resp, "", false
+	}
+
+	value, ok, err := c.cache.Get(ctx, key)
+	if err != nil || !ok || json.Unmarshal(value, &resp) != nil {
+		return openai.ChatCompletionResponse{}, key, false
+	}
+	resp.SetHeader(http.Header{cacheHeader: {cacheHit}})
+	return resp, key, true
+}
+
+// storeChatCompletion caches a successful response under key.
+func (c *Client) storeChatCompletion(ctx context.Context, key string, resp openai.ChatCompletionResponse) {
+	if key == "" || len(resp.Choices) == 0 {
+		return
+	}
+	if value, err := json.Marshal(resp); err == nil {
+		_ = c.cache.Set(ctx, key, value)
+	}
+}
+
+// IsCacheHit reports whether a chat completion response was served from the cache
+// configured with WithCache.
+func IsCacheHit(resp openai.ChatCompletionResponse) bool {
+	return resp.Header().Get(cacheHeader) == cacheHit
+}
+
+// MemoryCache is an in-memory Cache that evicts the least recently used entry
+// once it holds size entries. Entries expire after the TTL.
+type MemoryCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type memoryEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewMemoryCache creates a MemoryCache holding up to size entries.
+// A ttl of zero keeps entries until they are evicted.
+func NewMemoryCache(size int, ttl time.Duration) *MemoryCache {
+	return &MemoryCache{
+		size:    max(size, 1),
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Get implements the Cache interface.
+func (m *MemoryCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	el, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	e := el.Value.(*memoryEntry)
+	if !e.expires.IsZero() && time.Now().After(e.expires) {
+		m.order.Remove(el)
+		delete(m.entries, key)
+		return nil, false, nil
+	}
+	m.order.MoveToFront(el)
+	return e.value, true, nil
+}
+
+// Set implements the Cache interface.
+func (m *MemoryCache) Set(_ context.Context, key string, value []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var expires time.Time
+	if m.ttl > 0 {
+		expires = time.Now().Add(m.ttl)
+	}
+	if el, ok := m.entries[key]; ok {
+		el.Value = &memoryEntry{key: key, value: value, expires: expires}
+		m.order.MoveToFront(el)
+		return nil
+	}
+
+	m.entries[key] = m.order.PushFront(&memoryEntry{key: key, value: value, expires: expires})
+	for m.order.Len() > m.size {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryEntry).key)
+	}
+	return nil
+}
+
+// Len returns the number of entries, including expired ones not yet evicted.
+func (m *MemoryCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.order.Len()
+}
+
+// DiskCache is a Cache storing one file per entry below a directory,
+// so cached responses survive restarts. Entries expire after the TTL,
+// measured from the modification time of their file.
+type DiskCache struct {
+	dir string
+	ttl time.Duration
+}
+
+// NewDiskCache creates a DiskCache in dir, creating the directory if needed.
+// A ttl of zero keeps entries forever.
+func NewDiskCache(dir string, ttl time.Duration) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &DiskCache{dir: dir, ttl: ttl}, nil
+}
+
+// path shards entries by the first two characters of the key to keep directories small.
+func (d *DiskCache) path(key string) string {
+	if len(key) < 3 {
+		return filepath.Join(d.dir, key)
+	}
+	return filepath.Join(d.dir, key[:2], key)
+}
+
+// Get implements the Cache interface.
+func (d *DiskCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	p := d.path(key)
+	info, err := os.Stat(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if d.ttl > 0 && time.Since(info.ModTime()) > d.ttl {
+		_ = os.Remove(p)
+		return nil, false, nil
+	}
+
+	value, err := os.ReadFile(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Set implements the Cache interface. The entry is written to a temporary file
+// and renamed, so concurrent readers never see a partial value.
+func (d *DiskCache) Set(_ context.Context, key string, value []byte) error {
+	p := d.path(key)
+	if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(p), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(value); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), p); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package openai
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	openaisdk "github.com/sashabaranov/go-openai"
+)
+
+func TestMemoryCache_LRU(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryCache(2, 0)
+
+	_ = m.Set(ctx, "a", []byte("1"))
+	_ = m.Set(ctx, "b", []byte("2"))
+
+	// Touch a, so b is the least recently used entry.
+	if _, ok, _ := m.Get(ctx, "a"); !ok {
+		t.Fatal("Expected 'a' to be cached")
+	}
+	_ = m.Set(ctx, "c", []byte("3"))
+
+	if _, ok, _ := m.Get(ctx, "b"); ok {
+		t.Error("Expected 'b' to be evicted")
+	}
+
+	if v, ok, _ := m.Get(ctx, "a"); !ok || string(v) != "1" {
+		t.Errorf("Expected 'a' to hold '1', got '%s' (%v)", v, ok)
+	}
+
+	if m.Len() != 2 {
+		t.Errorf("Expected 2 entries, got %d", m.Len())
+	}
+}
+
+func TestMemoryCache_TTL(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryCache(10, 10*time.Millisecond)
+
+	_ = m.Set(ctx, "a", []byte("1"))
+	time.Sleep(20 * time.Millisecond)
+
+	if _, ok, _ := m.Get(ctx, "a"); ok {
+		t.Error("Expected expired entry to be missing")
+	}
+
+	if m.Len() != 0 {
+		t.Errorf("Expected expired entry to be removed, got %d entries", m.Len())
+	}
+}
+
+func TestDiskCache(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	d, err := NewDiskCache(dir, time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create disk cache: %v", err)
+	}
+
+	if _, ok, err := d.Get(ctx, "abcdef"); ok || err != nil {
+		t.Errorf("Expected clean miss, got (%v, %v)", ok, err)
+	}
+
+	if err := d.Set(ctx, "abcdef", []byte("value")); err != nil {
+		t.Fatalf("Failed to set entry: %v", err)
+	}
+
+	// A second cache over the same directory sees the entry.
+	reopened, _ := NewDiskCache(dir, time.Hour)
+	if v, ok, err := reopened.Get(ctx, "abcdef"); !ok || err != nil || string(v) != "value" {
+		t.Errorf("Expected 'value', got '%s' (%v, %v)", v, ok, err)
+	}
+
+	expired, _ := NewDiskCache(dir, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if _, ok, _ := expired.Get(ctx, "abcdef"); ok {
+		t.Error("Expected expired entry to be missing")
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	req := openaisdk.ChatCompletionRequest{
+		Model:     "gpt-4o-mini",
+		Messages:  []openaisdk.ChatCompletionMessage{{Role: openaisdk.ChatMessageRoleUser, Content: "hi"}},
+		LogitBias: map[string]int{"1": 1, "2": 2, "3": 3},
+	}
+
+	a, _ := cacheKey("openai", req)
+	b, _ := cacheKey("openai", req)
+	if a != b {
+		t.Errorf("Expected equal requests to share a key, got '%s' and '%s'", a, b)
+	}
+
+	if c, _ := cacheKey("deepseek", req); c == a {
+		t.Error("Expected different providers to use different keys")
+	}
+
+	req.Messages[0].Content = "hello"
+	if c, _ := cacheKey("openai", req); c == a {
+		t.Error("Expected different messages to use different keys")
+	}
+}
+
+func TestClient_WithCache(t *testing.T) {
+	tests := []struct {
+		name        string
+		temperature Option
+		calls       int32
+	}{
+		{"Deterministic temperature is cached", WithTemperature(0.1), 1},
+		{"Zero temperature is cached", WithZeroTemperature(), 1},
+		{"High temperature bypasses the cache", WithTemperature(0.9), 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				if body, _ := io.ReadAll(r.Body); !strings.Contains(string(body), `"temperature":`) {
+					t.Errorf("Expected the temperature to be sent, got %s", body)
+				}
+				_, _ = io.WriteString(w, `{"choices":[{"message":{"role":"assistant","content":"no"},"finish_reason":"stop"}],"usage":{"total_tokens":5}}`)
+			}))
+			defer server.Close()
+
+			client, err := New(
+				WithToken("test-token"),
+				WithBaseURL(server.URL),
+				tt.temperature,
+				WithCache(NewMemoryCache(10, time.Minute)),
+			)
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+
+			for i := range 3 {
+				resp, err := client.Completion(context.Background(), "", "is this spam?")
+				if err != nil {
+					t.Fatalf("Expected no error, got: %v", err)
+				}
+
+				if resp.Content != "no" {
+					t.Errorf("Expected content 'no', got '%s'", resp.Content)
+				}
+
+				wantHit := i > 0 && tt.calls == 1
+				if resp.CacheHit != wantHit {
+					t.Errorf("Call %d: expected cache hit %v, got %v", i, wantHit, resp.CacheHit)
+				}
+			}
+
+			if calls.Load() != tt.calls {
+				t.Errorf("Expected %d API calls, got %d", tt.calls, calls.Load())
+			}
+		})
+	}
+}
@@ -3,12 +3,16 @@ package openai
 import (
 	"net/http"
 	"strings"
+
+	"go.opentelemetry.io/otel/propagation"
 )
 
 // DefaultHeaderTransport is an http.RoundTripper that adds the given headers to
 type DefaultHeaderTransport struct {
 	Origin http.RoundTripper
 	Header http.Header
+	// Propagator, if set, injects the trace context of the request context into the headers.
+	Propagator propagation.TextMapPropagator
 }
 
 // RoundTrip implements the http.RoundTripper interface.
@@ -18,7 +22,12 @@ func (t *DefaultHeaderTransport) RoundTrip(req *http.Request) (*http.Response, e
 			req.Header.Add(key, value)
 		}
 	}
-	return t.Origin.RoundTrip(req)
+	if t.Propagator != nil {
+		t.Propagator.Inject(req.Context(), propagation.HeaderCarrier(req.Header))
+	}
+	resp, err := t.Origin.RoundTrip(req)
+	recordResponseHeader(req.Context(), resp)
+	return resp, err
 }
 
 // NewHeaders creates a new http.Header from the given slice of headers.
@@ -0,0 +1,138 @@
+package openai
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// ConversationOption configures a Conversation.
+type ConversationOption func(*Conversation)
+
+// WithMaxTurns keeps only the last n turns of the history, where a turn is a user
+// message with every reply and tool message that follows it. Zero keeps all turns.
+func WithMaxTurns(n int) ConversationOption {
+	return func(cv *Conversation) {
+		cv.maxTurns = n
+	}
+}
+
+// WithTokenBudget drops the oldest turns until the estimated prompt, including the
+// system prompt, fits in the given number of tokens. The current turn is always kept.
+// Zero disables the budget.
+func WithTokenBudget(tokens int) ConversationOption {
+	return func(cv *Conversation) {
+		cv.tokenBudget = tokens
+	}
+}
+
+// Conversation is a multi-turn chat with a Client that keeps the message history
+// between calls. It is safe for concurrent use; concurrent Send calls are serialized.
+type Conversation struct {
+	client *Client
+	system string
+
+	maxTurns    int
+	tokenBudget int
+
+	// sendMu serializes Send, so the history is not held locked during the API call.
+	sendMu  sync.Mutex
+	mu      sync.RWMutex
+	history []openai.ChatCompletionMessage
+}
+
+// NewConversation starts a conversation with the given system prompt.
+// An empty system prompt sends no system message.
+func (c *Client) NewConversation(system string, opts ...ConversationOption) *Conversation {
+	cv := &Conversation{client: c, system: system}
+	for _, opt := range opts {
+		opt(cv)
+	}
+	return cv
+}
+
+// Send adds the user message to the conversation and returns the reply.
+// Tools registered on the client are executed as in RunWithTools.
+// Both turns are appended to the history only when the request succeeds.
+func (cv *Conversation) Send(ctx context.Context, text string) (*Response, error) {
+	cv.sendMu.Lock()
+	defer cv.sendMu.Unlock()
+
+	cv.mu.RLock()
+	history := append(slices.Clone(cv.history), openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleUser,
+		Content: text,
+	})
+	cv.mu.RUnlock()
+
+	history = cv.trim(history)
+	messages := cv.withSystem(history)
+
+	resp, extended, err := cv.client.RunWithTools(ctx, messages)
+	if err != nil {
+		return nil, err
+	}
+
+	cv.mu.Lock()
+	cv.history = append(history, extended[len(messages):]...)
+	cv.mu.Unlock()
+	return resp, nil
+}
+
+// History returns a copy of the messages of the conversation, starting with the system prompt.
+func (cv *Conversation) History() []openai.ChatCompletionMessage {
+	cv.mu.RLock()
+	defer cv.mu.RUnlock()
+	return cv.withSystem(slices.Clone(cv.history))
+}
+
+// Reset clears the history, keeping the system prompt.
+func (cv *Conversation) Reset() {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	cv.history = nil
+}
+
+func (cv *Conversation) withSystem(history []openai.ChatCompletionMessage) []openai.ChatCompletionMessage {
+	if cv.system == "" {
+		return history
+	}
+	return append([]openai.ChatCompletionMessage{{
+		Role:    openai.ChatMessageRoleSystem,
+		Content: cv.system,
+	}}, history...)
+}
+
+// trim drops the oldest turns of history according to the trimming options.
+func (cv *Conversation) trim(history []openai.ChatCompletionMessage) []openai.ChatCompletionMessage {
+	starts := turnStarts(history)
+	if cv.maxTurns > 0 && len(starts) > cv.maxTurns {
+		starts = starts[len(starts)-cv.maxTurns:]
+	}
+	if cv.tokenBudget > 0 {
+		for len(starts) > 1 && cv.estimate(history[starts[0]:]) > cv.tokenBudget {
+			starts = starts[1:]
+		}
+	}
+	if len(starts) == 0 {
+		return history
+	}
+	return history[starts[0]:]
+}
+
+func (cv *Conversation) estimate(history []openai.ChatCompletionMessage) int {
+	return countTokens(cv.client.counter(), openai.ChatCompletionRequest{Messages: cv.withSystem(history)})
+}
+
+// turnStarts returns the index of every user message, each starting a turn.
+func turnStarts(history []openai.ChatCompletionMessage) []int {
+	var starts []int
+	for i, m := range history {
+		if m.Role == openai.ChatMessageRoleUser {
+			starts = append(starts, i)
+		}
+	}
+	return starts
+}
@@ -0,0 +1,50 @@
+package openai
+
+import (
+	"strings"
+	"testing"
+
+	openaisdk "github.com/sashabaranov/go-openai"
+)
+
+func TestConversation_Trim(t *testing.T) {
+	// Five finished turns followed by the current message.
+	var history []openaisdk.ChatCompletionMessage
+	for range 5 {
+		history = append(history,
+			openaisdk.ChatCompletionMessage{Role: openaisdk.ChatMessageRoleUser, Content: strings.Repeat("question ", 50)},
+			openaisdk.ChatCompletionMessage{Role: openaisdk.ChatMessageRoleAssistant, Content: strings.Repeat("answer ", 40)},
+		)
+	}
+	history = append(history, openaisdk.ChatCompletionMessage{Role: openaisdk.ChatMessageRoleUser, Content: "current"})
+
+	// A budget that fits exactly the last two turns and the current message.
+	client := &Client{}
+	budget := client.NewConversation("system").estimate(history[len(history)-5:])
+
+	tests := []struct {
+		name     string
+		opts     []ConversationOption
+		expected int
+	}{
+		{"No limits", nil, 11},
+		{"Max turns", []ConversationOption{WithMaxTurns(3)}, 5},
+		{"Token budget", []ConversationOption{WithTokenBudget(budget)}, 5},
+		{"Budget keeps the current turn", []ConversationOption{WithTokenBudget(1)}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cv := client.NewConversation("system", tt.opts...)
+			got := cv.trim(history)
+
+			if len(got) != tt.expected {
+				t.Errorf("Expected %d messages, got %d", tt.expected, len(got))
+			}
+
+			if got[0].Role != openaisdk.ChatMessageRoleUser || got[len(got)-1].Content != "current" {
+				t.Errorf("Expected whole turns ending with the current message, got %+v", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package openai_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	openaisdk "github.com/sashabaranov/go-openai"
+
+	"github.com/ysicing/openai/openai/openaitest"
+)
+
+func TestConversation_Send(t *testing.T) {
+	srv := openaitest.NewServer(t)
+	srv.Reply(openaitest.Text("Hi!"), openaitest.Text("Everest."))
+
+	cv := srv.Client().NewConversation("Be brief.")
+	if _, err := cv.Send(context.Background(), "Hello"); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	resp, err := cv.Send(context.Background(), "Highest mountain?")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if resp.Content != "Everest." {
+		t.Errorf("Expected content 'Everest.', got '%s'", resp.Content)
+	}
+
+	// The second request carries the whole first exchange.
+	sent := srv.ChatRequests()[1].Messages
+	if len(sent) != 4 || sent[0].Content != "Be brief." || sent[2].Content != "Hi!" {
+		t.Errorf("Expected system prompt and first exchange to be sent, got %+v", sent)
+	}
+
+	history := cv.History()
+	if len(history) != 5 || history[4].Content != "Everest." {
+		t.Errorf("Expected 5 messages in history, got %+v", history)
+	}
+
+	cv.Reset()
+	if history := cv.History(); len(history) != 1 || history[0].Role != openaisdk.ChatMessageRoleSystem {
+		t.Errorf("Expected only the system prompt after reset, got %+v", history)
+	}
+}
+
+func TestConversation_SendFailureKeepsHistory(t *testing.T) {
+	srv := openaitest.NewServer(t)
+	srv.Reply(openaitest.Error(http.StatusBadRequest, "invalid_request_error", "bad request"))
+
+	cv := srv.Client().NewConversation("")
+	if _, err := cv.Send(context.Background(), "Hello"); err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+
+	if history := cv.History(); len(history) != 0 {
+		t.Errorf("Expected empty history after a failed send, got %+v", history)
+	}
+}
@@ -0,0 +1,188 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/ysicing/openai/openai/tokenizer"
+)
+
+const (
+	defaultEmbeddingModel       = openai.SmallEmbedding3
+	defaultEmbeddingConcurrency = 4
+
+	// Inputs per embedding request accepted by the providers.
+	openAIEmbeddingBatchSize = 2048
+	azureEmbeddingBatchSize  = 16
+	zhipuEmbeddingBatchSize  = 64
+	geminiEmbeddingBatchSize = 100
+
+	// maxEmbeddingBatchTokens keeps a batch below the per-request token limit of the API.
+	maxEmbeddingBatchTokens = 250000
+)
+
+// EmbeddingResponse holds the vectors of an Embed call in input order.
+type EmbeddingResponse struct {
+	Vectors [][]float32
+	Usage   openai.Usage
+	// Provider is the name of the client that served the response.
+	Provider string
+}
+
+// embeddingBatch is a contiguous slice of the inputs sent in one request.
+type embeddingBatch struct {
+	offset int
+	texts  []string
+	tokens int
+}
+
+// Embed returns the embedding vector of every text, using the model set by WithEmbeddingModel.
+// Large inputs are split into batches sized for the provider, which are sent concurrently
+// (see WithEmbeddingConcurrency); the vectors keep the order of texts and Usage sums all batches.
+func (c *Client) Embed(ctx context.Context, texts []string) (*EmbeddingResponse, error) {
+	resp := &EmbeddingResponse{
+		Vectors:  make([][]float32, len(texts)),
+		Provider: c.name,
+	}
+	if len(texts) == 0 {
+		return resp, nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		sem      = make(chan struct{}, c.embeddingConcurrency)
+	)
+	counter := tokenizer.ForModel(string(c.embeddingModel))
+	for _, b := range splitEmbeddingBatches(texts, c.embeddingBatchSize, counter) {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			usage, err := c.embedBatch(ctx, b, resp.Vectors)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+					// Stop the remaining batches, the call fails anyway.
+					cancel()
+				}
+				return
+			}
+			addUsage(&resp.Usage, usage)
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, fmt.Errorf("embedding failed: %w", firstErr)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("embedding failed: %w", err)
+	}
+	return resp, nil
+}
+
+// EmbedOne returns the embedding vector of a single text.
+func (c *Client) EmbedOne(ctx context.Context, text string) ([]float32, error) {
+	resp, err := c.Embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Vectors[0], nil
+}
+
+// embedBatch sends one batch and stores its vectors at the batch offset of vectors.
+// Batches never overlap, so concurrent calls write disjoint elements.
+func (c *Client) embedBatch(ctx context.Context, b embeddingBatch, vectors [][]float32) (openai.Usage, error) {
+	ctx, op := c.telemetry.startEmbeddings(ctx, string(c.embeddingModel))
+	res, err := c.limiter.wait(ctx, b.tokens)
+	if err != nil {
+		op.end(ctx, "", "", nil, openai.Usage{}, err)
+		return openai.Usage{}, err
+	}
+
+	ctx, header := withResponseHeader(ctx)
+	r, err := c.client.CreateEmbeddings(ctx, openai.EmbeddingRequestStrings{
+		Input: b.texts,
+		Model: c.embeddingModel,
+	})
+	err = c.wrapError(err, header)
+	res.settle(r.Usage, err)
+	op.end(ctx, "", string(r.Model), nil, r.Usage, err)
+	if err != nil {
+		return openai.Usage{}, err
+	}
+	model := string(r.Model)
+	if model == "" {
+		model = string(c.embeddingModel)
+	}
+	c.track(ctx, model, r.Usage)
+
+	if len(r.Data) != len(b.texts) {
+		return openai.Usage{}, fmt.Errorf("expected %d embeddings, got %d", len(b.texts), len(r.Data))
+	}
+
+	for _, e := range r.Data {
+		if e.Index < 0 || e.Index >= len(b.texts) {
+			return openai.Usage{}, fmt.Errorf("embedding index %d out of range", e.Index)
+		}
+		vectors[b.offset+e.Index] = e.Embedding
+	}
+	return r.Usage, nil
+}
+
+// splitEmbeddingBatches cuts texts into batches of at most size inputs
+// and maxEmbeddingBatchTokens tokens.
+func splitEmbeddingBatches(texts []string, size int, counter tokenizer.Counter) []embeddingBatch {
+	var (
+		batches []embeddingBatch
+		start   int
+		tokens  int
+	)
+	for i, text := range texts {
+		n := counter.Count(text)
+		if i > start && (i-start == size || tokens+n > maxEmbeddingBatchTokens) {
+			batches = append(batches, embeddingBatch{offset: start, texts: texts[start:i], tokens: tokens})
+			start, tokens = i, 0
+		}
+		tokens += n
+	}
+	return append(batches, embeddingBatch{offset: start, texts: texts[start:], tokens: tokens})
+}
+
+// embeddingBatchSize returns the number of inputs per request the provider accepts.
+func embeddingBatchSize(cfg *config) int {
+	switch cfg.provider {
+	case Azure:
+		return azureEmbeddingBatchSize
+	case Gemini:
+		return geminiEmbeddingBatchSize
+	}
+	if u, err := url.Parse(cfg.baseURL); err == nil && strings.Contains(u.Host, "bigmodel") {
+		return zhipuEmbeddingBatchSize
+	}
+	return openAIEmbeddingBatchSize
+}
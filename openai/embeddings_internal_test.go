@@ -0,0 +1,63 @@
+package openai
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ysicing/openai/openai/tokenizer"
+)
+
+func TestSplitEmbeddingBatches(t *testing.T) {
+	half := strings.Repeat("a", maxEmbeddingBatchTokens/2*4)
+	long := strings.Repeat("a", maxEmbeddingBatchTokens*4)
+	tests := []struct {
+		name     string
+		texts    []string
+		size     int
+		expected []int
+	}{
+		{"Single batch", []string{"a", "b"}, 10, []int{2}},
+		{"Split by size", []string{"a", "b", "c", "d", "e"}, 2, []int{2, 2, 1}},
+		{"Split by tokens", []string{half, half, "a"}, 10, []int{2, 1}},
+		{"Oversized text is sent alone", []string{"a", long, "b"}, 10, []int{1, 1, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sizes []int
+			offset := 0
+			for _, b := range splitEmbeddingBatches(tt.texts, tt.size, tokenizer.Approximation{Other: 0.25}) {
+				if b.offset != offset {
+					t.Errorf("Expected batch offset %d, got %d", offset, b.offset)
+				}
+				offset += len(b.texts)
+				sizes = append(sizes, len(b.texts))
+			}
+
+			if !slices.Equal(sizes, tt.expected) {
+				t.Errorf("Expected batch sizes %v, got %v", tt.expected, sizes)
+			}
+		})
+	}
+}
+
+func TestEmbeddingBatchSize(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      *config
+		expected int
+	}{
+		{"OpenAI", &config{provider: OpenAI}, openAIEmbeddingBatchSize},
+		{"Azure", &config{provider: Azure}, azureEmbeddingBatchSize},
+		{"ZhiPu", &config{provider: OpenAI, baseURL: "https://open.bigmodel.cn/api/paas/v4"}, zhipuEmbeddingBatchSize},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := embeddingBatchSize(tt.cfg); got != tt.expected {
+				t.Errorf("Expected batch size %d, got %d", tt.expected, got)
+			}
+		})
+	}
+}
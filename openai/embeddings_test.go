@@ -0,0 +1,108 @@
+package openai_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ysicing/openai/openai"
+	"github.com/ysicing/openai/openai/openaitest"
+)
+
+// peakTransport records the peak number of concurrent requests, holding each one briefly.
+type peakTransport struct {
+	inFlight, peak atomic.Int32
+}
+
+func (t *peakTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	n := t.inFlight.Add(1)
+	defer t.inFlight.Add(-1)
+	for {
+		p := t.peak.Load()
+		if n <= p || t.peak.CompareAndSwap(p, n) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestClient_Embed(t *testing.T) {
+	srv := openaitest.NewServer(t)
+	transport := &peakTransport{}
+	client := srv.Client(
+		openai.WithHTTPTransport(transport),
+		openai.WithEmbeddingModel("embed-model"),
+		openai.WithEmbeddingBatchSize(3),
+		openai.WithEmbeddingConcurrency(2),
+	)
+
+	texts := make([]string, 10)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("text %d", i)
+	}
+
+	resp, err := client.Embed(context.Background(), texts)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	for i, v := range resp.Vectors {
+		if want := openaitest.Vector(texts[i], len(v)); !slices.Equal(v, want) {
+			t.Errorf("Expected vector %d to embed '%s', got %v", i, texts[i], v)
+		}
+	}
+
+	if resp.Usage.TotalTokens != 2*len(texts) {
+		t.Errorf("Expected usage summed over batches to be %d, got %d", 2*len(texts), resp.Usage.TotalTokens)
+	}
+
+	requests := srv.Requests()
+	if len(requests) != 4 {
+		t.Errorf("Expected 4 batches, got %d", len(requests))
+	}
+	for _, r := range requests {
+		if !strings.Contains(string(r.Body), `"model":"embed-model"`) {
+			t.Errorf("Expected the embedding model to be sent, got %s", r.Body)
+		}
+	}
+
+	if p := transport.peak.Load(); p > 2 {
+		t.Errorf("Expected at most 2 concurrent batches, got %d", p)
+	}
+}
+
+func TestClient_EmbedOne(t *testing.T) {
+	srv := openaitest.NewServer(t)
+	client := srv.Client()
+
+	v, err := client.EmbedOne(context.Background(), "hello")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if want := openaitest.Vector("hello", len(v)); len(v) == 0 || !slices.Equal(v, want) {
+		t.Errorf("Expected vector %v, got %v", want, v)
+	}
+
+	resp, err := client.Embed(context.Background(), nil)
+	if err != nil || len(resp.Vectors) != 0 {
+		t.Errorf("Expected empty response for no input, got (%v, %v)", resp, err)
+	}
+}
+
+func TestClient_EmbedError(t *testing.T) {
+	srv := openaitest.NewServer(t)
+	reply := openaitest.Error(http.StatusBadRequest, "invalid_request_error", "bad input")
+	srv.FailEmbeddings(&reply)
+
+	client := srv.Client(openai.WithEmbeddingBatchSize(1))
+	if _, err := client.Embed(context.Background(), []string{"a", "b", "c"}); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
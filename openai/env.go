@@ -0,0 +1,207 @@
+package openai
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Environment variables read by WithEnv for each provider and setting, in order of precedence.
+var envVars = map[string]map[string][]string{
+	OpenAI: {
+		"token":    {"OPENAI_API_KEY"},
+		"base_url": {"OPENAI_BASE_URL"},
+		"org_id":   {"OPENAI_ORG_ID"},
+		"model":    {"OPENAI_MODEL"},
+	},
+	Azure: {
+		"token":       {"AZURE_OPENAI_API_KEY"},
+		"base_url":    {"AZURE_OPENAI_ENDPOINT"},
+		"model":       {"AZURE_OPENAI_DEPLOYMENT", "OPENAI_MODEL"},
+		"api_version": {"AZURE_OPENAI_API_VERSION", "OPENAI_API_VERSION"},
+	},
+	Anthropic: {
+		"token":    {"ANTHROPIC_API_KEY"},
+		"base_url": {"ANTHROPIC_BASE_URL"},
+		"model":    {"ANTHROPIC_MODEL"},
+	},
+	Gemini: {
+		"token": {"GEMINI_API_KEY", "GOOGLE_API_KEY"},
+		"model": {"GEMINI_MODEL"},
+	},
+}
+
+// envSuffixes name the variables read under the prefix set with WithEnvPrefix.
+var envSuffixes = map[string]string{
+	"token":       "API_KEY",
+	"base_url":    "BASE_URL",
+	"org_id":      "ORG_ID",
+	"model":       "MODEL",
+	"api_version": "API_VERSION",
+}
+
+// proxyVars are the proxy variables read by WithEnv, in order of precedence.
+var proxyVars = []string{"HTTPS_PROXY", "https_proxy", "ALL_PROXY", "all_proxy"}
+
+// noProxyVars list the hosts that bypass a proxy read from the environment.
+var noProxyVars = []string{"NO_PROXY", "no_proxy"}
+
+// SettingSource tells where the value of a client setting came from.
+type SettingSource string
+
+const (
+	// SourceOption is a value set with an Option.
+	SourceOption SettingSource = "option"
+	// SourceEnv is a value read from an environment variable by WithEnv.
+	SourceEnv SettingSource = "env"
+	// SourceDefault is the built-in default, or no value.
+	SourceDefault SettingSource = "default"
+)
+
+// Setting is the resolved value of a client setting, see Settings.
+type Setting struct {
+	// Name is the setting, e.g. token or base_url, as in a config profile.
+	Name string
+	// Value is the resolved value, with secrets masked.
+	Value  string
+	Source SettingSource
+	// Env is the variable the value was read from, for SourceEnv.
+	Env string
+}
+
+// WithEnv returns a new Option that reads the settings not set by other options from the
+// environment when the client is created, whatever the order of the options:
+//
+//   - OPENAI_API_KEY, OPENAI_BASE_URL, OPENAI_ORG_ID and OPENAI_MODEL;
+//   - AZURE_OPENAI_API_KEY, AZURE_OPENAI_ENDPOINT, AZURE_OPENAI_DEPLOYMENT and
+//     AZURE_OPENAI_API_VERSION or OPENAI_API_VERSION instead with WithProvider(Azure);
+//   - ANTHROPIC_API_KEY, ANTHROPIC_BASE_URL and ANTHROPIC_MODEL with WithProvider(Anthropic);
+//   - GEMINI_API_KEY or GOOGLE_API_KEY and GEMINI_MODEL with WithProvider(Gemini);
+//   - HTTPS_PROXY or ALL_PROXY, where a socks5:// URL sets the SOCKS5 proxy, and NO_PROXY,
+//     whose hosts are reached directly as with http.ProxyFromEnvironment.
+//
+// The client ignores the environment unless this option is given.
+func WithEnv() Option {
+	return optionFunc(func(c *config) {
+		c.env = true
+	})
+}
+
+// WithEnvPrefix returns a new Option like WithEnv that first reads the variables
+// <prefix>API_KEY, <prefix>BASE_URL, <prefix>ORG_ID, <prefix>MODEL and <prefix>API_VERSION,
+// e.g. MYAPP_API_KEY for the prefix MYAPP_, so that an application can have its own settings.
+func WithEnvPrefix(prefix string) Option {
+	return optionFunc(func(c *config) {
+		c.env = true
+		c.envPrefix = prefix
+	})
+}
+
+// NewFromEnv creates a new client configured from the environment, see WithEnv.
+// The options take precedence over the environment.
+func NewFromEnv(opts ...Option) (*Client, error) {
+	return New(append([]Option{WithEnv()}, opts...)...)
+}
+
+// Settings reports the connection settings a client created with the options would use,
+// and whether each comes from an option, the environment or the default.
+func Settings(opts ...Option) []Setting {
+	cfg := newConfig(opts...)
+	fields := []struct {
+		name  string
+		value string
+	}{
+		{"provider", cfg.provider},
+		{"token", cfg.token},
+		{"base_url", cfg.baseURL},
+		{"org_id", cfg.orgID},
+		{"model", cfg.model},
+		{"api_version", cfg.apiVersion},
+		{"proxy_url", cfg.proxyURL},
+		{"socks_url", cfg.socksURL},
+	}
+	if len(cfg.tokens) > 0 && cfg.token == "" {
+		fields[1].value = cfg.tokens[0]
+	}
+
+	// Fill in the defaults New applies.
+	_ = cfg.valid()
+	defaults := map[string]string{
+		"provider": defaultProvider,
+		"base_url": cfg.endpoint(),
+		"model":    cfg.model,
+	}
+
+	settings := make([]Setting, 0, len(fields))
+	for _, f := range fields {
+		s := Setting{Name: f.name, Value: f.value, Source: SourceOption}
+		switch {
+		case cfg.envSources[f.name] != "":
+			s.Source, s.Env = SourceEnv, cfg.envSources[f.name]
+		case f.value == "" || f.name == "provider" && f.value == defaultProvider:
+			s.Source, s.Value = SourceDefault, defaults[f.name]
+		}
+		switch f.name {
+		case "token":
+			if s.Value != "" {
+				s.Value = maskKey(s.Value)
+			}
+		case "proxy_url", "socks_url":
+			if u, err := url.Parse(s.Value); err == nil && u.Scheme != "" {
+				s.Value = u.Redacted()
+			}
+		}
+		settings = append(settings, s)
+	}
+	return settings
+}
+
+// applyEnv sets the settings left empty by the options from the environment.
+func (cfg *config) applyEnv(lookup func(string) (string, bool)) {
+	cfg.envSources = map[string]string{}
+	get := func(names []string) (string, string) {
+		for _, name := range names {
+			if val, ok := lookup(name); ok && val != "" {
+				return val, name
+			}
+		}
+		return "", ""
+	}
+	set := func(field *string, setting string) {
+		if *field != "" {
+			return
+		}
+		var names []string
+		if cfg.envPrefix != "" {
+			names = append(names, cfg.envPrefix+envSuffixes[setting])
+		}
+		if val, name := get(append(names, envVars[cfg.provider][setting]...)); name != "" {
+			*field = val
+			cfg.envSources[setting] = name
+		}
+	}
+
+	if len(cfg.tokens) == 0 {
+		set(&cfg.token, "token")
+	}
+	set(&cfg.baseURL, "base_url")
+	set(&cfg.orgID, "org_id")
+	set(&cfg.model, "model")
+	set(&cfg.apiVersion, "api_version")
+
+	if cfg.proxyURL != "" || cfg.socksURL != "" {
+		return
+	}
+	val, name := get(proxyVars)
+	if name == "" {
+		return
+	}
+	cfg.noProxy, _ = get(noProxyVars)
+	if u, err := url.Parse(val); err == nil && strings.HasPrefix(u.Scheme, "socks5") {
+		// Keep the URL whole: its user info authenticates with the proxy.
+		cfg.socksURL = val
+		cfg.envSources["socks_url"] = name
+		return
+	}
+	cfg.proxyURL = val
+	cfg.envSources["proxy_url"] = name
+}
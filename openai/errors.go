@@ -0,0 +1,241 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// Errors that classify provider failures. Match them with errors.Is; the failure itself
+// is an *Error carrying the status code, request ID and retry hint of the response.
+var (
+	// ErrRateLimited reports a request rejected by a rate limit. Retrying later may succeed.
+	ErrRateLimited = errors.New("rate limited")
+	// ErrAuth reports a missing, invalid or unauthorised API key.
+	ErrAuth = errors.New("authentication failed")
+	// ErrContextLengthExceeded reports a prompt longer than the context window of the model.
+	ErrContextLengthExceeded = errors.New("context length exceeded")
+	// ErrContentFiltered reports a prompt or completion blocked by a content filter.
+	ErrContentFiltered = errors.New("content filtered")
+	// ErrQuotaExhausted reports an account without quota or balance left.
+	ErrQuotaExhausted = errors.New("quota exhausted")
+	// ErrModelNotFound reports an unknown model, or an unknown deployment for Azure.
+	ErrModelNotFound = errors.New("model not found")
+	// ErrEmptyResponse reports a successful response without choices.
+	ErrEmptyResponse = errors.New("empty response from API: no choices returned")
+)
+
+// Provider error codes of each class. OpenAI, Azure, DeepSeek and Anthropic use names,
+// Gemini uses gRPC status names and ZhiPu uses numeric codes.
+var (
+	quotaCodes         = []string{"insufficient_quota", "1113"}
+	contextLengthCodes = []string{"context_length_exceeded", "string_above_max_length", "1261"}
+	contentFilterCodes = []string{"content_filter", "content_policy_violation", "ResponsibleAIPolicyViolation", "1301"}
+	authCodes          = []string{
+		"invalid_api_key", "authentication_error", "permission_error", "UNAUTHENTICATED", "PERMISSION_DENIED",
+		"1000", "1001", "1002", "1003", "1004",
+	}
+	rateLimitCodes     = []string{"rate_limit_exceeded", "rate_limit_error", "RESOURCE_EXHAUSTED", "1302", "1303", "1305"}
+	modelNotFoundCodes = []string{"model_not_found", "DeploymentNotFound", "1211"}
+)
+
+// Error is a failed provider request. It unwraps to its class, e.g. ErrRateLimited,
+// and to the underlying error, e.g. *openai.APIError.
+type Error struct {
+	// Kind is the class of the failure, one of the Err variables, or nil if unknown.
+	Kind error
+	// Provider is the name of the client, see Client.Name.
+	Provider string
+	// StatusCode is the HTTP status of the response.
+	StatusCode int
+	// Code is the error code reported by the provider, e.g. rate_limit_exceeded or 1113.
+	Code string
+	// Message is the error message reported by the provider.
+	Message string
+	// RequestID identifies the request in the logs of the provider, if reported.
+	RequestID string
+	// RetryAfter is the wait the provider asked for before retrying, if any.
+	RetryAfter time.Duration
+	// Err is the underlying error.
+	Err error
+
+	// shouldRetry is the x-should-retry header of the response, if any.
+	shouldRetry *bool
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	if e.Kind != nil {
+		b.WriteString(e.Kind.Error())
+		b.WriteString(": ")
+	}
+	if e.StatusCode != 0 {
+		fmt.Fprintf(&b, "status %d", e.StatusCode)
+		if e.Code != "" {
+			fmt.Fprintf(&b, " (%s)", e.Code)
+		}
+		b.WriteString(": ")
+	}
+	b.WriteString(e.Message)
+	if e.RequestID != "" {
+		fmt.Fprintf(&b, " [request %s]", e.RequestID)
+	}
+	return b.String()
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Kind == nil {
+		return []error{e.Err}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// Retryable reports whether the same request may succeed later, following the rules of
+// RetryTransport: rate limits and 408, 502, 503 and 504 responses are transient, while a 500
+// is only retried when the server asks for it with x-should-retry: true.
+func (e *Error) Retryable() bool {
+	if e.shouldRetry != nil {
+		return *e.shouldRetry
+	}
+	switch e.Kind {
+	case ErrRateLimited:
+		return true
+	case ErrQuotaExhausted:
+		return false
+	}
+	return retryableStatus(e.StatusCode)
+}
+
+// newError converts a backend failure into an *Error. Failures without a provider
+// response, such as network errors and cancellations, are returned unchanged.
+func newError(provider string, err error, header http.Header) error {
+	var e *Error
+	if err == nil || errors.As(err, &e) {
+		return err
+	}
+
+	e = &Error{Provider: provider, Err: err}
+	var (
+		apiErr *openai.APIError
+		reqErr *openai.RequestError
+	)
+	switch {
+	case errors.As(err, &apiErr):
+		e.StatusCode = apiErr.HTTPStatusCode
+		e.Message = apiErr.Message
+		if apiErr.Code != nil {
+			e.Code = fmt.Sprint(apiErr.Code)
+		}
+		// Azure reports the content filter verdict as the inner error.
+		if apiErr.InnerError != nil && apiErr.InnerError.Code != "" && e.Code == "" {
+			e.Code = apiErr.InnerError.Code
+		}
+		e.Kind = classifyError(e.StatusCode, e.Code+" "+apiErr.Type, e.Message)
+	case errors.As(err, &reqErr) && reqErr.HTTPStatusCode != 0:
+		e.StatusCode = reqErr.HTTPStatusCode
+		e.Message = strings.TrimSpace(string(reqErr.Body))
+		if e.Message == "" {
+			e.Message = reqErr.Error()
+		}
+		e.Kind = classifyError(e.StatusCode, "", e.Message)
+	case errors.Is(err, ErrContentFiltered):
+		e.Kind = ErrContentFiltered
+		e.Message = err.Error()
+	default:
+		return err
+	}
+
+	if header != nil {
+		e.RequestID = requestID(header)
+		e.RetryAfter, _ = retryAfter(header, e.Kind == ErrRateLimited)
+		if v, ok := serverShouldRetry(header); ok {
+			e.shouldRetry = &v
+		}
+	}
+	return e
+}
+
+// classifyError maps the status, codes and message of an error response to its class.
+// codes holds the code and type reported by the provider, separated by spaces.
+func classifyError(status int, codes, message string) error {
+	fields := strings.Fields(codes)
+	has := func(known []string) bool {
+		return slices.ContainsFunc(fields, func(f string) bool { return slices.Contains(known, f) })
+	}
+	msg := strings.ToLower(message)
+	mentions := func(phrases ...string) bool {
+		return slices.ContainsFunc(phrases, func(p string) bool { return strings.Contains(msg, p) })
+	}
+
+	switch {
+	// Quota comes first: OpenAI reports it with status 429.
+	case has(quotaCodes) || status == http.StatusPaymentRequired ||
+		mentions("insufficient balance", "credit balance is too low"):
+		return ErrQuotaExhausted
+	case has(contextLengthCodes) ||
+		mentions("maximum context length", "prompt is too long", "exceeds the maximum number of tokens"):
+		return ErrContextLengthExceeded
+	case has(contentFilterCodes):
+		return ErrContentFiltered
+	case has(authCodes) || status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return ErrAuth
+	case has(rateLimitCodes) || status == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case has(modelNotFoundCodes) || mentions("model not exist") ||
+		status == http.StatusNotFound && mentions("model", "deployment"):
+		return ErrModelNotFound
+	}
+	return nil
+}
+
+// requestID returns the ID a provider assigned to a request, if any.
+func requestID(h http.Header) string {
+	for _, key := range []string{"X-Request-Id", "Request-Id", "Apim-Request-Id"} {
+		if id := h.Get(key); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+type responseHeaderKey struct{}
+
+// responseHeader receives the headers of the last response of a call, which the go-openai
+// errors do not carry. DefaultHeaderTransport fills it in.
+type responseHeader struct {
+	mu     sync.Mutex
+	header http.Header
+}
+
+// withResponseHeader returns a context that records the response headers of its requests.
+func withResponseHeader(ctx context.Context) (context.Context, *responseHeader) {
+	h := &responseHeader{}
+	return context.WithValue(ctx, responseHeaderKey{}, h), h
+}
+
+// recordResponseHeader stores the headers of resp if ctx asks for them.
+func recordResponseHeader(ctx context.Context, resp *http.Response) {
+	if h, ok := ctx.Value(responseHeaderKey{}).(*responseHeader); ok && resp != nil {
+		h.mu.Lock()
+		h.header = resp.Header
+		h.mu.Unlock()
+	}
+}
+
+func (h *responseHeader) get() http.Header {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.header
+}
+
+// wrapError converts a backend failure of the client into an *Error.
+func (c *Client) wrapError(err error, h *responseHeader) error {
+	return newError(c.name, err, h.get())
+}
@@ -0,0 +1,234 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	openaisdk "github.com/sashabaranov/go-openai"
+)
+
+func TestClient_TypedErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		status   int
+		header   http.Header
+		body     string
+		kind     error
+		code     string
+	}{
+		{
+			"OpenAI rate limit", OpenAI, http.StatusTooManyRequests,
+			http.Header{"X-Request-Id": {"req_123"}, "Retry-After-Ms": {"1500"}},
+			`{"error":{"message":"Rate limit reached for gpt-4o","type":"requests","code":"rate_limit_exceeded"}}`,
+			ErrRateLimited, "rate_limit_exceeded",
+		},
+		{
+			"OpenAI quota", OpenAI, http.StatusTooManyRequests, nil,
+			`{"error":{"message":"You exceeded your current quota","type":"insufficient_quota","code":"insufficient_quota"}}`,
+			ErrQuotaExhausted, "insufficient_quota",
+		},
+		{
+			"OpenAI context length", OpenAI, http.StatusBadRequest, nil,
+			`{"error":{"message":"This model's maximum context length is 128000 tokens.","type":"invalid_request_error","code":"context_length_exceeded"}}`,
+			ErrContextLengthExceeded, "context_length_exceeded",
+		},
+		{
+			"OpenAI invalid key", OpenAI, http.StatusUnauthorized, nil,
+			`{"error":{"message":"Incorrect API key provided","type":"invalid_request_error","code":"invalid_api_key"}}`,
+			ErrAuth, "invalid_api_key",
+		},
+		{
+			"OpenAI model not found", OpenAI, http.StatusNotFound, nil,
+			`{"error":{"message":"The model gpt-9 does not exist","type":"invalid_request_error","code":"model_not_found"}}`,
+			ErrModelNotFound, "model_not_found",
+		},
+		{
+			"Azure content filter", Azure, http.StatusBadRequest,
+			http.Header{"Apim-Request-Id": {"apim_1"}},
+			`{"error":{"message":"The response was filtered","param":"prompt","code":"content_filter","status":400,"innererror":{"code":"ResponsibleAIPolicyViolation"}}}`,
+			ErrContentFiltered, "content_filter",
+		},
+		{
+			"Azure deployment not found", Azure, http.StatusNotFound, nil,
+			`{"error":{"code":"DeploymentNotFound","message":"The API deployment for this resource does not exist."}}`,
+			ErrModelNotFound, "DeploymentNotFound",
+		},
+		{
+			"DeepSeek balance", OpenAI, http.StatusPaymentRequired, nil,
+			`{"error":{"message":"Insufficient Balance","type":"unknown_error","code":"invalid_request_error"}}`,
+			ErrQuotaExhausted, "invalid_request_error",
+		},
+		{
+			"ZhiPu arrears", OpenAI, http.StatusTooManyRequests, nil,
+			`{"error":{"code":"1113","message":"您的账户已欠费，请充值后重试。"}}`,
+			ErrQuotaExhausted, "1113",
+		},
+		{
+			"ZhiPu sensitive content", OpenAI, http.StatusBadRequest, nil,
+			`{"error":{"code":"1301","message":"系统检测到输入或生成内容可能包含不安全或敏感内容"}}`,
+			ErrContentFiltered, "1301",
+		},
+		{
+			"Server error", OpenAI, http.StatusInternalServerError, nil,
+			`{"error":{"message":"The server had an error","type":"server_error"}}`,
+			nil, "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				for k, v := range tt.header {
+					w.Header()[k] = v
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				_, _ = io.WriteString(w, tt.body)
+			}))
+			defer srv.Close()
+
+			client, err := New(WithToken("test-token"), WithProvider(tt.provider), WithBaseURL(srv.URL), WithName("test"))
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+
+			_, err = client.Completion(context.Background(), "", "hi")
+			var e *Error
+			if !errors.As(err, &e) {
+				t.Fatalf("Expected an *Error, got: %v", err)
+			}
+			if e.Kind != tt.kind || (tt.kind != nil && !errors.Is(err, tt.kind)) {
+				t.Errorf("Expected kind %v, got %v", tt.kind, e.Kind)
+			}
+			if e.StatusCode != tt.status || e.Code != tt.code || e.Provider != "test" {
+				t.Errorf("Expected status %d and code %s, got %+v", tt.status, tt.code, e)
+			}
+			var apiErr *openaisdk.APIError
+			if !errors.As(err, &apiErr) {
+				t.Error("Expected the go-openai error to stay reachable")
+			}
+		})
+	}
+}
+
+func TestError_Details(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-Request-Id", "req_123")
+		w.Header().Set("Retry-After-Ms", "1500")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = io.WriteString(w, `{"error":{"message":"Rate limit reached","type":"requests","code":"rate_limit_exceeded"}}`)
+	}))
+	defer srv.Close()
+
+	client, err := New(WithToken("test-token"), WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	_, err = client.Completion(context.Background(), "", "hi")
+	var e *Error
+	if !errors.As(err, &e) {
+		t.Fatalf("Expected an *Error, got: %v", err)
+	}
+	if e.RequestID != "req_123" || e.RetryAfter != 1500*time.Millisecond || !e.Retryable() {
+		t.Errorf("Expected request ID and retry hint, got %+v", e)
+	}
+	expected := "rate limited: status 429 (rate_limit_exceeded): Rate limit reached [request req_123]"
+	if !strings.HasSuffix(err.Error(), expected) {
+		t.Errorf("Expected message ending in %q, got %q", expected, err.Error())
+	}
+
+	// Streams and embeddings report the same errors.
+	if _, err := client.CompletionStream(context.Background(), "", "hi"); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Expected ErrRateLimited from the stream, got: %v", err)
+	}
+	if _, err := client.Embed(context.Background(), []string{"a"}); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Expected ErrRateLimited from embeddings, got: %v", err)
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		codes   string
+		message string
+		kind    error
+	}{
+		{"Anthropic auth", http.StatusUnauthorized, "authentication_error authentication_error", "invalid x-api-key", ErrAuth},
+		{"Anthropic prompt too long", http.StatusBadRequest, "invalid_request_error", "prompt is too long: 210000 tokens > 200000 maximum", ErrContextLengthExceeded},
+		{"Anthropic credit", http.StatusBadRequest, "invalid_request_error", "Your credit balance is too low", ErrQuotaExhausted},
+		{"Gemini exhausted", http.StatusTooManyRequests, "RESOURCE_EXHAUSTED", "You exceeded your current quota", ErrRateLimited},
+		{"Gemini permission", http.StatusForbidden, "PERMISSION_DENIED", "API key not valid", ErrAuth},
+		{"DeepSeek model", http.StatusBadRequest, "invalid_request_error", "Model Not Exist", ErrModelNotFound},
+		{"Ollama model", http.StatusNotFound, "", `model "llama9" not found, try pulling it first`, ErrModelNotFound},
+		{"Wrong path", http.StatusNotFound, "", "404 page not found", nil},
+		{"Bad request", http.StatusBadRequest, "invalid_request_error", "Invalid value for temperature", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyError(tt.status, tt.codes, tt.message); got != tt.kind {
+				t.Errorf("Expected %v, got %v", tt.kind, got)
+			}
+		})
+	}
+}
+
+func TestError_Retryable(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		code     string
+		header   http.Header
+		expected bool
+	}{
+		{"Rate limited", http.StatusTooManyRequests, "rate_limit_exceeded", nil, true},
+		{"Quota exhausted", http.StatusTooManyRequests, "insufficient_quota", nil, false},
+		{"Request timeout", http.StatusRequestTimeout, "", nil, true},
+		{"Service unavailable", http.StatusServiceUnavailable, "", nil, true},
+		{"Internal server error", http.StatusInternalServerError, "", nil, false},
+		{"Internal server error the server asks to retry", http.StatusInternalServerError, "", http.Header{"X-Should-Retry": {"true"}}, true},
+		{"Service unavailable the server asks not to retry", http.StatusServiceUnavailable, "", http.Header{"X-Should-Retry": {"false"}}, false},
+		{"Bad request", http.StatusBadRequest, "", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiErr := &openaisdk.APIError{HTTPStatusCode: tt.status, Message: "failed"}
+			if tt.code != "" {
+				apiErr.Code = tt.code
+			}
+
+			var e *Error
+			if !errors.As(newError("openai", apiErr, tt.header), &e) {
+				t.Fatal("Expected an *Error")
+			}
+			if got := e.Retryable(); got != tt.expected {
+				t.Errorf("Expected Retryable() %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestClient_EmptyResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"choices":[]}`)
+	}))
+	defer srv.Close()
+
+	client, err := New(WithToken("test-token"), WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if _, err := client.Completion(context.Background(), "", "hi"); !errors.Is(err, ErrEmptyResponse) {
+		t.Errorf("Expected ErrEmptyResponse, got: %v", err)
+	}
+}
@@ -0,0 +1,4 @@
+package openai
+
+// Exported for the tests of package openai_test.
+const DefaultStructuredAttempts = defaultStructuredAttempts
@@ -0,0 +1,220 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// FailoverClass is a set of error classes on which a FallbackClient moves on to the next client.
+type FailoverClass uint8
+
+const (
+	// FailoverTimeout covers request timeouts.
+	FailoverTimeout FailoverClass = 1 << iota
+	// FailoverNetwork covers connection failures.
+	FailoverNetwork
+	// FailoverServerError covers 5xx responses.
+	FailoverServerError
+	// FailoverRateLimit covers 429 responses.
+	FailoverRateLimit
+	// FailoverContentFilter covers prompts or completions blocked by a content filter.
+	FailoverContentFilter
+
+	// DefaultFailover fails over on every class except content filtering.
+	DefaultFailover = FailoverTimeout | FailoverNetwork | FailoverServerError | FailoverRateLimit
+)
+
+var errNoClients = errors.New("fallback requires at least one client")
+
+// FallbackEvent describes the outcome of one client attempt of a FallbackClient.
+type FallbackEvent struct {
+	// Provider is the name of the client, see Client.Name.
+	Provider string
+	// Err is the failure that caused the failover; nil when the client served the request.
+	Err error
+}
+
+// FallbackOption configures a FallbackClient.
+type FallbackOption func(*FallbackClient)
+
+// WithFailoverOn sets the error classes that trigger a failover. Defaults to DefaultFailover.
+func WithFailoverOn(classes FailoverClass) FallbackOption {
+	return func(f *FallbackClient) {
+		f.failoverOn = classes
+	}
+}
+
+// WithAttemptTimeout gives every client attempt its own deadline,
+// so a hanging provider fails over instead of consuming the whole context.
+func WithAttemptTimeout(d time.Duration) FallbackOption {
+	return func(f *FallbackClient) {
+		f.attemptTimeout = d
+	}
+}
+
+// WithFallbackObserver registers a function called after every client attempt.
+func WithFallbackObserver(fn func(FallbackEvent)) FallbackOption {
+	return func(f *FallbackClient) {
+		f.observer = fn
+	}
+}
+
+// FallbackClient sends requests to an ordered list of clients,
+// moving on to the next one when a request fails with a failover error class.
+type FallbackClient struct {
+	clients        []*Client
+	failoverOn     FailoverClass
+	attemptTimeout time.Duration
+	observer       func(FallbackEvent)
+}
+
+// NewFallbackClient creates a FallbackClient that tries the clients in the given order.
+func NewFallbackClient(clients []*Client, opts ...FallbackOption) (*FallbackClient, error) {
+	if len(clients) == 0 {
+		return nil, errNoClients
+	}
+	f := &FallbackClient{
+		clients:    clients,
+		failoverOn: DefaultFailover,
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f, nil
+}
+
+// Completion is the fallback counterpart of Client.Completion.
+// Response.Provider names the client that served the response.
+func (f *FallbackClient) Completion(
+	ctx context.Context,
+	prompt, content string,
+) (*Response, error) {
+	return runFallback(ctx, f, func(ctx context.Context, c *Client) (*Response, error) {
+		resp, err := c.Completion(ctx, prompt, content)
+		if err == nil && resp.FinishReason == openai.FinishReasonContentFilter {
+			return resp, ErrContentFiltered
+		}
+		return resp, err
+	})
+}
+
+// ImageCompletion is the fallback counterpart of Client.ImageCompletion.
+func (f *FallbackClient) ImageCompletion(
+	ctx context.Context,
+	image, prompt, content string,
+) (*Response, error) {
+	return runFallback(ctx, f, func(ctx context.Context, c *Client) (*Response, error) {
+		resp, err := c.ImageCompletion(ctx, image, prompt, content)
+		if err == nil && resp.FinishReason == openai.FinishReasonContentFilter {
+			return resp, ErrContentFiltered
+		}
+		return resp, err
+	})
+}
+
+// FallbackChatResponse is a chat completion served by one of the clients of a FallbackClient.
+type FallbackChatResponse struct {
+	openai.ChatCompletionResponse
+	// Provider is the name of the client that served the response.
+	Provider string
+}
+
+// CreateChatCompletionWithMessage is the fallback counterpart of Client.CreateChatCompletionWithMessage.
+// FallbackChatResponse.Provider names the client that served the response.
+func (f *FallbackClient) CreateChatCompletionWithMessage(
+	ctx context.Context,
+	messages []openai.ChatCompletionMessage,
+) (FallbackChatResponse, error) {
+	return runFallback(ctx, f, func(ctx context.Context, c *Client) (FallbackChatResponse, error) {
+		resp, err := c.CreateChatCompletionWithMessage(ctx, messages)
+		if err == nil && len(resp.Choices) > 0 && resp.Choices[0].FinishReason == openai.FinishReasonContentFilter {
+			err = ErrContentFiltered
+		}
+		return FallbackChatResponse{ChatCompletionResponse: resp, Provider: c.name}, err
+	})
+}
+
+// runFallback calls fn with each client in turn until one succeeds or fails with an
+// error that does not trigger a failover. When every client fails, the errors are joined.
+func runFallback[T any](
+	ctx context.Context,
+	f *FallbackClient,
+	fn func(context.Context, *Client) (T, error),
+) (T, error) {
+	var (
+		zero T
+		errs []error
+	)
+	for _, c := range f.clients {
+		attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+		if f.attemptTimeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, f.attemptTimeout)
+		}
+		resp, err := fn(attemptCtx, c)
+		cancel()
+
+		if f.observer != nil {
+			f.observer(FallbackEvent{Provider: c.name, Err: err})
+		}
+		if err == nil {
+			return resp, nil
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", c.name, err))
+		// Give up when the caller is gone or the error would recur on any provider.
+		if ctx.Err() != nil || f.failoverOn&classifyFailover(err) == 0 {
+			break
+		}
+	}
+	if len(errs) == 1 {
+		return zero, errors.Unwrap(errs[0])
+	}
+	return zero, errors.Join(errs...)
+}
+
+// classifyFailover maps an error to its failover class, or zero when it has none.
+func classifyFailover(err error) FailoverClass {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return FailoverTimeout
+	}
+
+	// Raw backend errors are classified like the *Error a client returns.
+	var e *Error
+	if errors.As(newError("", err, nil), &e) {
+		switch e.Kind {
+		case ErrContentFiltered:
+			return FailoverContentFilter
+		case ErrRateLimited, ErrQuotaExhausted:
+			// Another provider has its own limits and balance.
+			return FailoverRateLimit
+		}
+		return classifyStatus(e.StatusCode)
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return FailoverTimeout
+		}
+		return FailoverNetwork
+	}
+	return 0
+}
+
+func classifyStatus(status int) FailoverClass {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return FailoverRateLimit
+	case status == http.StatusRequestTimeout || status == http.StatusGatewayTimeout:
+		return FailoverTimeout
+	case status >= http.StatusInternalServerError:
+		return FailoverServerError
+	}
+	return 0
+}
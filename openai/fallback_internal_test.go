@@ -0,0 +1,24 @@
+package openai
+
+import "testing"
+
+func TestClientName(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      *config
+		expected string
+	}{
+		{"Explicit name", &config{name: "primary", provider: OpenAI}, "primary"},
+		{"Base URL host", &config{provider: OpenAI, baseURL: "https://api.deepseek.com/v1"}, "api.deepseek.com"},
+		{"Provider", &config{provider: OpenAI}, OpenAI},
+		{"Azure", &config{provider: Azure, baseURL: "https://example.openai.azure.com"}, Azure},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := clientName(tt.cfg); got != tt.expected {
+				t.Errorf("Expected name '%s', got '%s'", tt.expected, got)
+			}
+		})
+	}
+}
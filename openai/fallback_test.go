@@ -0,0 +1,180 @@
+package openai_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	openaisdk "github.com/sashabaranov/go-openai"
+
+	"github.com/ysicing/openai/openai"
+	"github.com/ysicing/openai/openai/openaitest"
+)
+
+func TestNewFallbackClient_NoClients(t *testing.T) {
+	if _, err := openai.NewFallbackClient(nil); err == nil {
+		t.Error("Expected error for no clients, got nil")
+	}
+}
+
+func TestFallbackClient_Completion(t *testing.T) {
+	tests := []struct {
+		name     string
+		reply    openaitest.Reply
+		on       openai.FailoverClass
+		provider string
+		wantErr  bool
+	}{
+		{"Primary succeeds", openaitest.Text("from primary"), openai.DefaultFailover, "primary", false},
+		{"Fail over on 5xx", openaitest.InternalError, openai.DefaultFailover, "backup", false},
+		{"Fail over on 429", openaitest.RateLimited, openai.DefaultFailover, "backup", false},
+		{"No failover on 400", openaitest.Error(http.StatusBadRequest, "", "bad request"), openai.DefaultFailover, "", true},
+		{"No failover on disabled class", openaitest.InternalError, openai.FailoverRateLimit, "", true},
+		{"Fail over on content filter", openaitest.Reply{FinishReason: openaisdk.FinishReasonContentFilter}, openai.FailoverContentFilter, "backup", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary := openaitest.NewServer(t)
+			primary.Reply(tt.reply)
+			backup := openaitest.NewServer(t)
+			backup.Reply(openaitest.Text("from backup"))
+
+			var events []openai.FallbackEvent
+			f, err := openai.NewFallbackClient(
+				[]*openai.Client{primary.Client(openai.WithName("primary")), backup.Client(openai.WithName("backup"))},
+				openai.WithFailoverOn(tt.on),
+				openai.WithFallbackObserver(func(e openai.FallbackEvent) { events = append(events, e) }),
+			)
+			if err != nil {
+				t.Fatalf("Failed to create fallback client: %v", err)
+			}
+
+			resp, err := f.Completion(context.Background(), "", "hi")
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+
+			if resp.Provider != tt.provider {
+				t.Errorf("Expected provider '%s', got '%s'", tt.provider, resp.Provider)
+			}
+
+			last := events[len(events)-1]
+			if last.Provider != tt.provider || last.Err != nil {
+				t.Errorf("Expected last event to report '%s' serving, got %+v", tt.provider, last)
+			}
+		})
+	}
+}
+
+func TestFallbackClient_AllFail(t *testing.T) {
+	primary := openaitest.NewServer(t)
+	primary.Reply(openaitest.Error(http.StatusServiceUnavailable, "", "unavailable"))
+	backup := openaitest.NewServer(t)
+	backup.Reply(openaitest.Error(http.StatusBadGateway, "", "bad gateway"))
+
+	f, err := openai.NewFallbackClient([]*openai.Client{
+		primary.Client(openai.WithName("primary")),
+		backup.Client(openai.WithName("backup")),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create fallback client: %v", err)
+	}
+
+	_, err = f.CreateChatCompletionWithMessage(context.Background(), []openaisdk.ChatCompletionMessage{
+		{Role: openaisdk.ChatMessageRoleUser, Content: "hi"},
+	})
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+
+	var apiErr *openaisdk.APIError
+	if !errors.As(err, &apiErr) {
+		t.Errorf("Expected joined error to expose the API errors, got %v", err)
+	}
+}
+
+func TestFallbackClient_ChatProvider(t *testing.T) {
+	// The primary fails the messages that ask for it, so concurrent calls are served by different clients.
+	primary := openaitest.NewServer(t)
+	primary.OnChat(func(req openaisdk.ChatCompletionRequest) openaitest.Reply {
+		if req.Messages[0].Content == "fail" {
+			return openaitest.InternalError
+		}
+		return openaitest.Text("from primary")
+	})
+	backup := openaitest.NewServer(t)
+	backup.OnChat(func(openaisdk.ChatCompletionRequest) openaitest.Reply { return openaitest.Text("from backup") })
+
+	f, err := openai.NewFallbackClient([]*openai.Client{
+		primary.Client(openai.WithName("primary")),
+		backup.Client(openai.WithName("backup")),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create fallback client: %v", err)
+	}
+
+	tests := []struct {
+		content  string
+		provider string
+	}{
+		{"ok", "primary"},
+		{"fail", "backup"},
+	}
+
+	var wg sync.WaitGroup
+	for _, tt := range tests {
+		wg.Go(func() {
+			resp, err := f.CreateChatCompletionWithMessage(context.Background(), []openaisdk.ChatCompletionMessage{
+				{Role: openaisdk.ChatMessageRoleUser, Content: tt.content},
+			})
+			if err != nil {
+				t.Errorf("Expected no error for '%s', got: %v", tt.content, err)
+				return
+			}
+			if resp.Provider != tt.provider || resp.Choices[0].Message.Content != "from "+tt.provider {
+				t.Errorf("Expected '%s' to be served by '%s', got '%s' from '%s'",
+					tt.content, tt.provider, resp.Choices[0].Message.Content, resp.Provider)
+			}
+		})
+	}
+	wg.Wait()
+}
+
+func TestFallbackClient_AttemptTimeout(t *testing.T) {
+	release := make(chan struct{})
+	slow := openaitest.NewServer(t)
+	slow.OnChat(func(openaisdk.ChatCompletionRequest) openaitest.Reply {
+		<-release
+		return openaitest.Text("from slow")
+	})
+	defer close(release)
+	backup := openaitest.NewServer(t)
+	backup.Reply(openaitest.Text("from backup"))
+
+	f, err := openai.NewFallbackClient(
+		[]*openai.Client{slow.Client(openai.WithName("slow")), backup.Client(openai.WithName("backup"))},
+		openai.WithAttemptTimeout(50*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create fallback client: %v", err)
+	}
+
+	resp, err := f.Completion(context.Background(), "", "hi")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if resp.Provider != "backup" {
+		t.Errorf("Expected provider 'backup', got '%s'", resp.Provider)
+	}
+}
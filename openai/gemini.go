@@ -0,0 +1,596 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// defaultGeminiURL is the address of the Gemini API.
+const defaultGeminiURL = "https://generativelanguage.googleapis.com/v1beta"
+
+// geminiBlockReasons are the finish reasons of a candidate withheld by safety filters.
+var geminiBlockReasons = []string{"SAFETY", "RECITATION", "BLOCKLIST", "PROHIBITED_CONTENT", "SPII", "IMAGE_SAFETY"}
+
+// SafetyError reports a Gemini prompt or response blocked by safety filters.
+// It matches ErrContentFiltered.
+type SafetyError struct {
+	// Reason is the block reason, e.g. SAFETY, PROHIBITED_CONTENT or RECITATION.
+	Reason string
+	// Prompt reports whether the prompt was blocked rather than the response.
+	Prompt bool
+	// Categories lists the harm categories that caused the block, if reported.
+	Categories []string
+}
+
+func (e *SafetyError) Error() string {
+	target := "response"
+	if e.Prompt {
+		target = "prompt"
+	}
+	msg := fmt.Sprintf("gemini: %s blocked: %s", target, e.Reason)
+	if len(e.Categories) > 0 {
+		msg += " (" + strings.Join(e.Categories, ", ") + ")"
+	}
+	return msg
+}
+
+// Is reports whether target is ErrContentFiltered.
+func (e *SafetyError) Is(target error) bool {
+	return target == ErrContentFiltered
+}
+
+// geminiBackend speaks the Gemini generateContent API.
+type geminiBackend struct {
+	baseURL string
+	token   string
+	client  *http.Client
+	// images downloads remote images without the credentials and headers of client.
+	images *http.Client
+}
+
+type geminiPart struct {
+	Text    string `json:"text,omitempty"`
+	Thought bool   `json:"thought,omitempty"`
+
+	InlineData       *geminiBlob             `json:"inlineData,omitempty"`
+	FunctionCall     *geminiFunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *geminiFunctionResponse `json:"functionResponse,omitempty"`
+}
+
+type geminiBlob struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data"`
+}
+
+type geminiFunctionCall struct {
+	ID   string          `json:"id,omitempty"`
+	Name string          `json:"name"`
+	Args json.RawMessage `json:"args,omitempty"`
+}
+
+type geminiFunctionResponse struct {
+	Name     string          `json:"name"`
+	Response json.RawMessage `json:"response"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiFunction struct {
+	Name                 string `json:"name"`
+	Description          string `json:"description,omitempty"`
+	ParametersJSONSchema any    `json:"parametersJsonSchema,omitempty"`
+}
+
+type geminiTool struct {
+	FunctionDeclarations []geminiFunction `json:"functionDeclarations"`
+}
+
+type geminiGenerationConfig struct {
+	Temperature        *float32 `json:"temperature,omitempty"`
+	TopP               float32  `json:"topP,omitempty"`
+	MaxOutputTokens    int      `json:"maxOutputTokens,omitempty"`
+	StopSequences      []string `json:"stopSequences,omitempty"`
+	PresencePenalty    float32  `json:"presencePenalty,omitempty"`
+	FrequencyPenalty   float32  `json:"frequencyPenalty,omitempty"`
+	Seed               *int     `json:"seed,omitempty"`
+	ResponseMimeType   string   `json:"responseMimeType,omitempty"`
+	ResponseJSONSchema any      `json:"responseJsonSchema,omitempty"`
+}
+
+type geminiRequest struct {
+	SystemInstruction *geminiContent         `json:"systemInstruction,omitempty"`
+	Contents          []geminiContent        `json:"contents"`
+	Tools             []geminiTool           `json:"tools,omitempty"`
+	ToolConfig        map[string]any         `json:"toolConfig,omitempty"`
+	GenerationConfig  geminiGenerationConfig `json:"generationConfig"`
+}
+
+type geminiSafetyRating struct {
+	Category    string `json:"category"`
+	Probability string `json:"probability"`
+	Blocked     bool   `json:"blocked"`
+}
+
+type geminiUsage struct {
+	PromptTokenCount        int `json:"promptTokenCount"`
+	CandidatesTokenCount    int `json:"candidatesTokenCount"`
+	ThoughtsTokenCount      int `json:"thoughtsTokenCount"`
+	CachedContentTokenCount int `json:"cachedContentTokenCount"`
+	TotalTokenCount         int `json:"totalTokenCount"`
+}
+
+type geminiResponse struct {
+	Candidates []struct {
+		Content       geminiContent        `json:"content"`
+		FinishReason  string               `json:"finishReason"`
+		SafetyRatings []geminiSafetyRating `json:"safetyRatings"`
+	} `json:"candidates"`
+	PromptFeedback *struct {
+		BlockReason   string               `json:"blockReason"`
+		SafetyRatings []geminiSafetyRating `json:"safetyRatings"`
+	} `json:"promptFeedback"`
+	UsageMetadata *geminiUsage `json:"usageMetadata"`
+	ModelVersion  string       `json:"modelVersion"`
+	ResponseID    string       `json:"responseId"`
+}
+
+func (b *geminiBackend) CreateChatCompletion(
+	ctx context.Context,
+	req openai.ChatCompletionRequest,
+) (openai.ChatCompletionResponse, error) {
+	body, err := b.chatRequest(ctx, req)
+	if err != nil {
+		return openai.ChatCompletionResponse{}, err
+	}
+	resp, err := b.post(ctx, req.Model, ":generateContent", body)
+	if err != nil {
+		return openai.ChatCompletionResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	var r geminiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return openai.ChatCompletionResponse{}, fmt.Errorf("gemini: invalid response: %w", err)
+	}
+	if err := r.blocked(); err != nil {
+		return openai.ChatCompletionResponse{}, err
+	}
+
+	out := openai.ChatCompletionResponse{
+		ID:     r.ResponseID,
+		Object: "chat.completion",
+		Model:  r.ModelVersion,
+		Usage:  r.UsageMetadata.openai(),
+	}
+	for i, c := range r.Candidates {
+		msg := openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant}
+		msg.Content, msg.ReasoningContent, msg.ToolCalls = c.Content.openai(0)
+		out.Choices = append(out.Choices, openai.ChatCompletionChoice{
+			Index:        i,
+			Message:      msg,
+			FinishReason: geminiFinishReason(c.FinishReason, len(msg.ToolCalls) > 0),
+		})
+	}
+	return out, nil
+}
+
+func (b *geminiBackend) CreateChatCompletionStream(
+	ctx context.Context,
+	req openai.ChatCompletionRequest,
+) (ChatCompletionStream, error) {
+	body, err := b.chatRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := b.post(ctx, req.Model, ":streamGenerateContent?alt=sse", body)
+	if err != nil {
+		return nil, err
+	}
+	return &geminiStream{body: resp.Body, events: newSSEReader(resp.Body)}, nil
+}
+
+func (b *geminiBackend) CreateEmbeddings(
+	ctx context.Context,
+	conv openai.EmbeddingRequestConverter,
+) (openai.EmbeddingResponse, error) {
+	req := conv.Convert()
+	var inputs []string
+	switch in := req.Input.(type) {
+	case string:
+		inputs = []string{in}
+	case []string:
+		inputs = in
+	default:
+		return openai.EmbeddingResponse{}, fmt.Errorf("gemini: unsupported embedding input %T", req.Input)
+	}
+
+	model := "models/" + geminiModel(string(req.Model))
+	type embedRequest struct {
+		Model                string        `json:"model"`
+		Content              geminiContent `json:"content"`
+		OutputDimensionality int           `json:"outputDimensionality,omitempty"`
+	}
+	var r struct {
+		Requests []embedRequest `json:"requests"`
+	}
+	for _, text := range inputs {
+		r.Requests = append(r.Requests, embedRequest{
+			Model:                model,
+			Content:              geminiContent{Parts: []geminiPart{{Text: text}}},
+			OutputDimensionality: req.Dimensions,
+		})
+	}
+	body, err := json.Marshal(r)
+	if err != nil {
+		return openai.EmbeddingResponse{}, err
+	}
+	resp, err := b.post(ctx, string(req.Model), ":batchEmbedContents", body)
+	if err != nil {
+		return openai.EmbeddingResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	var e struct {
+		Embeddings []struct {
+			Values []float32 `json:"values"`
+		} `json:"embeddings"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&e); err != nil {
+		return openai.EmbeddingResponse{}, fmt.Errorf("gemini: invalid response: %w", err)
+	}
+
+	// The API reports no token usage for embeddings.
+	out := openai.EmbeddingResponse{Object: "list", Model: req.Model}
+	for i, vec := range e.Embeddings {
+		out.Data = append(out.Data, openai.Embedding{Object: "embedding", Index: i, Embedding: vec.Values})
+	}
+	return out, nil
+}
+
+// chatRequest translates an OpenAI chat completion request to a generateContent request.
+// System messages become the system instruction, tool results become user function
+// responses and consecutive messages of the same role are merged into one turn.
+func (b *geminiBackend) chatRequest(ctx context.Context, req openai.ChatCompletionRequest) ([]byte, error) {
+	r := geminiRequest{
+		GenerationConfig: geminiGenerationConfig{
+			Temperature:      &req.Temperature,
+			TopP:             req.TopP,
+			MaxOutputTokens:  req.MaxCompletionTokens,
+			StopSequences:    req.Stop,
+			PresencePenalty:  req.PresencePenalty,
+			FrequencyPenalty: req.FrequencyPenalty,
+			Seed:             req.Seed,
+		},
+	}
+	if r.GenerationConfig.MaxOutputTokens == 0 {
+		r.GenerationConfig.MaxOutputTokens = req.MaxTokens
+	}
+
+	if f := req.ResponseFormat; f != nil {
+		switch {
+		case f.Type == openai.ChatCompletionResponseFormatTypeJSONSchema && f.JSONSchema != nil:
+			r.GenerationConfig.ResponseMimeType = "application/json"
+			r.GenerationConfig.ResponseJSONSchema = f.JSONSchema.Schema
+		case f.Type == openai.ChatCompletionResponseFormatTypeJSONObject:
+			r.GenerationConfig.ResponseMimeType = "application/json"
+		}
+	}
+
+	var functions []geminiFunction
+	for _, tool := range req.Tools {
+		if tool.Function == nil {
+			continue
+		}
+		functions = append(functions, geminiFunction{
+			Name:                 tool.Function.Name,
+			Description:          tool.Function.Description,
+			ParametersJSONSchema: tool.Function.Parameters,
+		})
+	}
+	if len(functions) > 0 {
+		r.Tools = []geminiTool{{FunctionDeclarations: functions}}
+		if choice, ok := req.ToolChoice.(string); ok {
+			modes := map[string]string{"auto": "AUTO", "required": "ANY", "none": "NONE"}
+			if mode := modes[choice]; mode != "" {
+				r.ToolConfig = map[string]any{"functionCallingConfig": map[string]string{"mode": mode}}
+			}
+		}
+	}
+
+	// Function responses are matched to the function that was called by its call ID.
+	toolNames := make(map[string]string)
+	var system []geminiPart
+	for _, m := range req.Messages {
+		role := "user"
+		var parts []geminiPart
+
+		switch m.Role {
+		case openai.ChatMessageRoleSystem, openai.ChatMessageRoleDeveloper:
+			system = append(system, geminiPart{Text: m.Content})
+			continue
+		case openai.ChatMessageRoleTool:
+			name := m.Name
+			if name == "" {
+				name = toolNames[m.ToolCallID]
+			}
+			parts = append(parts, geminiPart{
+				FunctionResponse: &geminiFunctionResponse{Name: name, Response: geminiToolResult(m.Content)},
+			})
+		case openai.ChatMessageRoleAssistant:
+			role = "model"
+			if m.Content != "" {
+				parts = append(parts, geminiPart{Text: m.Content})
+			}
+			for _, call := range m.ToolCalls {
+				toolNames[call.ID] = call.Function.Name
+				args := json.RawMessage(call.Function.Arguments)
+				if !json.Valid(args) {
+					args = json.RawMessage("{}")
+				}
+				parts = append(parts, geminiPart{
+					FunctionCall: &geminiFunctionCall{Name: call.Function.Name, Args: args},
+				})
+			}
+		default:
+			if m.Content != "" {
+				parts = append(parts, geminiPart{Text: m.Content})
+			}
+			for _, part := range m.MultiContent {
+				switch part.Type {
+				case openai.ChatMessagePartTypeText:
+					parts = append(parts, geminiPart{Text: part.Text})
+				case openai.ChatMessagePartTypeImageURL:
+					if part.ImageURL == nil {
+						continue
+					}
+					mimeType, data, err := inlineImage(ctx, b.images, part.ImageURL.URL)
+					if err != nil {
+						return nil, fmt.Errorf("gemini: %w", err)
+					}
+					parts = append(parts, geminiPart{InlineData: &geminiBlob{MimeType: mimeType, Data: data}})
+				}
+			}
+		}
+
+		if len(parts) == 0 {
+			continue
+		}
+		if n := len(r.Contents); n > 0 && r.Contents[n-1].Role == role {
+			r.Contents[n-1].Parts = append(r.Contents[n-1].Parts, parts...)
+			continue
+		}
+		r.Contents = append(r.Contents, geminiContent{Role: role, Parts: parts})
+	}
+	if len(system) > 0 {
+		r.SystemInstruction = &geminiContent{Parts: system}
+	}
+	return json.Marshal(r)
+}
+
+// geminiToolResult wraps a tool result in the JSON object the API expects.
+func geminiToolResult(content string) json.RawMessage {
+	trimmed := strings.TrimSpace(content)
+	if strings.HasPrefix(trimmed, "{") && json.Valid([]byte(trimmed)) {
+		return json.RawMessage(trimmed)
+	}
+	data, _ := json.Marshal(map[string]string{"content": content})
+	return data
+}
+
+// geminiModel strips the models/ prefix of a resource name.
+func geminiModel(model string) string {
+	return strings.TrimPrefix(model, "models/")
+}
+
+// ListModels returns the models available to the API key, following the pages of the list.
+func (b *geminiBackend) ListModels(ctx context.Context) (openai.ModelsList, error) {
+	var list openai.ModelsList
+	query := url.Values{"pageSize": {"1000"}}
+	for {
+		var page struct {
+			Models []struct {
+				Name string `json:"name"`
+			} `json:"models"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		if err := getJSON(ctx, b.client, b.url("/models?"+query.Encode()), b.header(), &page, parseGeminiError); err != nil {
+			return openai.ModelsList{}, fmt.Errorf("gemini: %w", err)
+		}
+		for _, m := range page.Models {
+			list.Models = append(list.Models, openai.Model{ID: geminiModel(m.Name), Object: "model", OwnedBy: "google"})
+		}
+		if page.NextPageToken == "" {
+			return list, nil
+		}
+		query.Set("pageToken", page.NextPageToken)
+	}
+}
+
+// post sends a request for a model method, turning error responses into *openai.APIError.
+func (b *geminiBackend) post(ctx context.Context, model, method string, body []byte) (*http.Response, error) {
+	endpoint := b.url("/models/" + url.PathEscape(geminiModel(model)) + method)
+	return postJSON(ctx, b.client, endpoint, b.header(), body, parseGeminiError)
+}
+
+func (b *geminiBackend) url(path string) string {
+	return strings.TrimSuffix(b.baseURL, "/") + path
+}
+
+func (b *geminiBackend) header() http.Header {
+	header := make(http.Header)
+	if b.token != "" {
+		header.Set("X-Goog-Api-Key", b.token)
+	}
+	return header
+}
+
+func parseGeminiError(status int, body []byte) error {
+	type geminiError struct {
+		Error struct {
+			Message string `json:"message"`
+			Status  string `json:"status"`
+		} `json:"error"`
+	}
+	var e geminiError
+	if json.Unmarshal(body, &e) != nil {
+		// Streamed requests may report the error inside an array.
+		var list []geminiError
+		if json.Unmarshal(body, &list) == nil && len(list) > 0 {
+			e = list[0]
+		}
+	}
+	if e.Error.Message == "" {
+		e.Error.Message = strings.TrimSpace(string(body))
+	}
+	return &openai.APIError{
+		HTTPStatusCode: status,
+		Code:           e.Error.Status,
+		Message:        e.Error.Message,
+		Type:           e.Error.Status,
+	}
+}
+
+// blocked returns a *SafetyError if the prompt or the first candidate was blocked.
+func (r geminiResponse) blocked() error {
+	if f := r.PromptFeedback; f != nil && f.BlockReason != "" {
+		return &SafetyError{Reason: f.BlockReason, Prompt: true, Categories: geminiBlockedCategories(f.SafetyRatings)}
+	}
+	if len(r.Candidates) > 0 && slices.Contains(geminiBlockReasons, r.Candidates[0].FinishReason) {
+		c := r.Candidates[0]
+		return &SafetyError{Reason: c.FinishReason, Categories: geminiBlockedCategories(c.SafetyRatings)}
+	}
+	return nil
+}
+
+func geminiBlockedCategories(ratings []geminiSafetyRating) []string {
+	var categories []string
+	for _, r := range ratings {
+		if r.Blocked || r.Probability == "HIGH" {
+			categories = append(categories, r.Category)
+		}
+	}
+	return categories
+}
+
+// openai returns the text, thoughts and function calls of a candidate. Calls without
+// an ID are numbered from index, as the API only reports IDs for some models.
+func (c geminiContent) openai(index int) (content, reasoning string, calls []openai.ToolCall) {
+	for _, p := range c.Parts {
+		switch {
+		case p.FunctionCall != nil:
+			i := index + len(calls)
+			id := p.FunctionCall.ID
+			if id == "" {
+				id = fmt.Sprintf("call_%d", i)
+			}
+			args := string(p.FunctionCall.Args)
+			if args == "" {
+				args = "{}"
+			}
+			calls = append(calls, openai.ToolCall{
+				Index:    &i,
+				ID:       id,
+				Type:     openai.ToolTypeFunction,
+				Function: openai.FunctionCall{Name: p.FunctionCall.Name, Arguments: args},
+			})
+		case p.Thought:
+			reasoning += p.Text
+		default:
+			content += p.Text
+		}
+	}
+	return content, reasoning, calls
+}
+
+// openai converts usage metadata; thinking tokens are billed as completion tokens.
+func (u *geminiUsage) openai() openai.Usage {
+	if u == nil {
+		return openai.Usage{}
+	}
+	usage := openai.Usage{
+		PromptTokens:     u.PromptTokenCount,
+		CompletionTokens: u.CandidatesTokenCount + u.ThoughtsTokenCount,
+		TotalTokens:      u.TotalTokenCount,
+	}
+	if usage.TotalTokens == 0 {
+		usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	}
+	if u.CachedContentTokenCount > 0 {
+		usage.PromptTokensDetails = &openai.PromptTokensDetails{CachedTokens: u.CachedContentTokenCount}
+	}
+	if u.ThoughtsTokenCount > 0 {
+		usage.CompletionTokensDetails = &openai.CompletionTokensDetails{ReasoningTokens: u.ThoughtsTokenCount}
+	}
+	return usage
+}
+
+func geminiFinishReason(reason string, toolCalls bool) openai.FinishReason {
+	switch {
+	case reason == "":
+		return ""
+	case toolCalls:
+		return openai.FinishReasonToolCalls
+	case reason == "MAX_TOKENS":
+		return openai.FinishReasonLength
+	}
+	return openai.FinishReasonStop
+}
+
+// geminiStream converts the responses of a streamed generation into chunks.
+type geminiStream struct {
+	body   io.ReadCloser
+	events *sseReader
+	// calls counts the function calls so far, which index the tool call deltas.
+	calls int
+}
+
+func (s *geminiStream) Recv() (openai.ChatCompletionStreamResponse, error) {
+	_, data, err := s.events.next()
+	if err != nil {
+		return openai.ChatCompletionStreamResponse{}, err
+	}
+
+	var r geminiResponse
+	if err := json.Unmarshal(data, &r); err != nil {
+		return openai.ChatCompletionStreamResponse{}, fmt.Errorf("gemini: invalid stream chunk: %w", err)
+	}
+	if err := r.blocked(); err != nil {
+		return openai.ChatCompletionStreamResponse{}, err
+	}
+
+	chunk := openai.ChatCompletionStreamResponse{
+		ID:     r.ResponseID,
+		Object: "chat.completion.chunk",
+		Model:  r.ModelVersion,
+	}
+	if r.UsageMetadata != nil {
+		usage := r.UsageMetadata.openai()
+		chunk.Usage = &usage
+	}
+	if len(r.Candidates) > 0 {
+		c := r.Candidates[0]
+		var delta openai.ChatCompletionStreamChoiceDelta
+		delta.Content, delta.ReasoningContent, delta.ToolCalls = c.Content.openai(s.calls)
+		s.calls += len(delta.ToolCalls)
+		chunk.Choices = []openai.ChatCompletionStreamChoice{{
+			Delta:        delta,
+			FinishReason: geminiFinishReason(c.FinishReason, s.calls > 0),
+		}}
+	}
+	return chunk, nil
+}
+
+func (s *geminiStream) Close() error {
+	return s.body.Close()
+}
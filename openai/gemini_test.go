@@ -0,0 +1,296 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	openaisdk "github.com/sashabaranov/go-openai"
+)
+
+func TestNew_Gemini(t *testing.T) {
+	if _, err := New(WithProvider(Gemini), WithToken("key")); !errors.Is(err, errorsMissingModel) {
+		t.Errorf("Expected missing model error, got: %v", err)
+	}
+
+	cfg := &config{provider: Gemini}
+	if got := cfg.endpoint(); got != defaultGeminiURL {
+		t.Errorf("Expected endpoint %s, got %s", defaultGeminiURL, got)
+	}
+	if got := providerName(cfg); got != "gcp.gemini" {
+		t.Errorf("Expected provider name gcp.gemini, got %s", got)
+	}
+	if got := embeddingBatchSize(cfg); got != geminiEmbeddingBatchSize {
+		t.Errorf("Expected batch size %d, got %d", geminiEmbeddingBatchSize, got)
+	}
+}
+
+func TestGeminiBackend_ChatRequest(t *testing.T) {
+	images := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Goog-Api-Key") != "" {
+			t.Error("Expected no credentials on image downloads")
+		}
+		w.Header().Set("Content-Type", "image/jpeg")
+		_, _ = w.Write([]byte{0xff, 0xd8, 0xff})
+	}))
+	defer images.Close()
+
+	b := &geminiBackend{images: images.Client()}
+	body, err := b.chatRequest(context.Background(), openaisdk.ChatCompletionRequest{
+		Model:       "gemini-2.5-flash",
+		Temperature: 0.5,
+		MaxTokens:   256,
+		Messages: []openaisdk.ChatCompletionMessage{
+			{Role: openaisdk.ChatMessageRoleSystem, Content: "Be brief."},
+			{Role: openaisdk.ChatMessageRoleUser, MultiContent: []openaisdk.ChatMessagePart{
+				{Type: openaisdk.ChatMessagePartTypeText, Text: "Compare"},
+				{Type: openaisdk.ChatMessagePartTypeImageURL, ImageURL: &openaisdk.ChatMessageImageURL{URL: "data:image/png;base64,iVBORw0KGgo="}},
+				{Type: openaisdk.ChatMessagePartTypeImageURL, ImageURL: &openaisdk.ChatMessageImageURL{URL: images.URL + "/cat.jpg"}},
+			}},
+			{Role: openaisdk.ChatMessageRoleAssistant, ToolCalls: []openaisdk.ToolCall{{
+				ID:       "call_0",
+				Type:     openaisdk.ToolTypeFunction,
+				Function: openaisdk.FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`},
+			}}},
+			{Role: openaisdk.ChatMessageRoleTool, ToolCallID: "call_0", Content: "sunny"},
+		},
+		Tools: []openaisdk.Tool{{
+			Type:     openaisdk.ToolTypeFunction,
+			Function: &openaisdk.FunctionDefinition{Name: "get_weather", Parameters: json.RawMessage(`{"type":"object"}`)},
+		}},
+		ToolChoice: "required",
+		ResponseFormat: &openaisdk.ChatCompletionResponseFormat{
+			Type:       openaisdk.ChatCompletionResponseFormatTypeJSONSchema,
+			JSONSchema: &openaisdk.ChatCompletionResponseFormatJSONSchema{Name: "answer", Schema: json.RawMessage(`{"type":"object"}`)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to build request: %v", err)
+	}
+
+	var r geminiRequest
+	if err := json.Unmarshal(body, &r); err != nil {
+		t.Fatalf("Failed to decode request: %v", err)
+	}
+	if r.SystemInstruction == nil || r.SystemInstruction.Parts[0].Text != "Be brief." {
+		t.Errorf("Expected system instruction, got %+v", r.SystemInstruction)
+	}
+	if c := r.GenerationConfig; *c.Temperature != 0.5 || c.MaxOutputTokens != 256 || c.ResponseMimeType != "application/json" {
+		t.Errorf("Expected generation config, got %+v", c)
+	}
+	if len(r.Contents) != 3 || r.Contents[1].Role != "model" || r.Contents[2].Role != "user" {
+		t.Fatalf("Expected user, model and user turns, got %+v", r.Contents)
+	}
+
+	parts := r.Contents[0].Parts
+	if parts[1].InlineData.MimeType != "image/png" || parts[1].InlineData.Data != "iVBORw0KGgo=" {
+		t.Errorf("Expected inline data URL image, got %+v", parts[1].InlineData)
+	}
+	if parts[2].InlineData.MimeType != "image/jpeg" || parts[2].InlineData.Data != "/9j/" {
+		t.Errorf("Expected downloaded image, got %+v", parts[2].InlineData)
+	}
+	if call := r.Contents[1].Parts[0].FunctionCall; call.Name != "get_weather" || string(call.Args) != `{"city":"Paris"}` {
+		t.Errorf("Expected function call, got %+v", call)
+	}
+	if resp := r.Contents[2].Parts[0].FunctionResponse; resp.Name != "get_weather" || string(resp.Response) != `{"content":"sunny"}` {
+		t.Errorf("Expected function response matched by call ID, got %+v", resp)
+	}
+	if r.Tools[0].FunctionDeclarations[0].Name != "get_weather" {
+		t.Errorf("Expected function declarations, got %+v", r.Tools)
+	}
+}
+
+func TestClient_Gemini(t *testing.T) {
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models/gemini-2.5-flash:generateContent" {
+			t.Errorf("Expected generateContent, got %s", r.URL.Path)
+		}
+		header = r.Header
+		_, _ = io.WriteString(w, `{
+			"candidates":[{"content":{"role":"model","parts":[{"text":"Thinking...","thought":true},{"text":"Paris"}]},"finishReason":"STOP"}],
+			"usageMetadata":{"promptTokenCount":10,"candidatesTokenCount":2,"thoughtsTokenCount":5,"cachedContentTokenCount":4,"totalTokenCount":17},
+			"modelVersion":"gemini-2.5-flash","responseId":"resp_1"
+		}`)
+	}))
+	defer srv.Close()
+
+	client, err := New(WithProvider(Gemini), WithBaseURL(srv.URL), WithToken("key"), WithModel("gemini-2.5-flash"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Completion(context.Background(), "", "Capital of France?")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if resp.Content != "Paris" || resp.FinishReason != openaisdk.FinishReasonStop {
+		t.Errorf("Expected translated response, got %+v", resp)
+	}
+	u := resp.Usage
+	if u.PromptTokens != 10 || u.CompletionTokens != 7 || u.TotalTokens != 17 ||
+		u.PromptTokensDetails.CachedTokens != 4 || u.CompletionTokensDetails.ReasoningTokens != 5 {
+		t.Errorf("Expected usage metadata mapped to usage, got %+v", u)
+	}
+	if header.Get("X-Goog-Api-Key") != "key" || header.Get("Authorization") != "" {
+		t.Errorf("Expected API key header, got %v", header)
+	}
+}
+
+func TestClient_GeminiSafety(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		prompt   bool
+		reason   string
+		category string
+	}{
+		{
+			"Prompt blocked",
+			`{"promptFeedback":{"blockReason":"SAFETY","safetyRatings":[{"category":"HARM_CATEGORY_HARASSMENT","probability":"HIGH"}]}}`,
+			true, "SAFETY", "HARM_CATEGORY_HARASSMENT",
+		},
+		{
+			"Response blocked",
+			`{"candidates":[{"finishReason":"PROHIBITED_CONTENT","safetyRatings":[{"category":"HARM_CATEGORY_DANGEROUS_CONTENT","probability":"MEDIUM","blocked":true}]}]}`,
+			false, "PROHIBITED_CONTENT", "HARM_CATEGORY_DANGEROUS_CONTENT",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = io.WriteString(w, tt.body)
+			}))
+			defer srv.Close()
+
+			client, err := New(WithProvider(Gemini), WithBaseURL(srv.URL), WithToken("key"), WithModel("gemini-2.5-flash"))
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+
+			_, err = client.Completion(context.Background(), "", "hi")
+			var safetyErr *SafetyError
+			if !errors.As(err, &safetyErr) {
+				t.Fatalf("Expected a SafetyError, got: %v", err)
+			}
+			if safetyErr.Prompt != tt.prompt || safetyErr.Reason != tt.reason || len(safetyErr.Categories) != 1 || safetyErr.Categories[0] != tt.category {
+				t.Errorf("Expected %s block of %s, got %+v", tt.reason, tt.category, safetyErr)
+			}
+			if classifyFailover(err) != FailoverContentFilter {
+				t.Errorf("Expected the content filter class, got %v", classifyFailover(err))
+			}
+		})
+	}
+}
+
+func TestClient_GeminiStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models/gemini-2.5-flash:streamGenerateContent" || r.URL.Query().Get("alt") != "sse" {
+			t.Errorf("Expected streamGenerateContent with alt=sse, got %s", r.URL)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, data := range []string{
+			`{"candidates":[{"content":{"role":"model","parts":[{"text":"Hello"}]}}],"usageMetadata":{"promptTokenCount":5,"candidatesTokenCount":1,"totalTokenCount":6}}`,
+			`{"candidates":[{"content":{"role":"model","parts":[{"text":", world"}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":5,"candidatesTokenCount":3,"totalTokenCount":8}}`,
+		} {
+			_, _ = io.WriteString(w, "data: "+data+"\n\n")
+		}
+	}))
+	defer srv.Close()
+
+	client, err := New(WithProvider(Gemini), WithBaseURL(srv.URL), WithToken("key"), WithModel("gemini-2.5-flash"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	stream, err := client.CompletionStream(context.Background(), "", "hi")
+	if err != nil {
+		t.Fatalf("CompletionStream failed: %v", err)
+	}
+	defer stream.Close()
+
+	resp, err := stream.Response()
+	if err != nil {
+		t.Fatalf("Response failed: %v", err)
+	}
+	if resp.Content != "Hello, world" || resp.Usage.TotalTokens != 8 || resp.FinishReason != openaisdk.FinishReasonStop {
+		t.Errorf("Expected assembled stream, got %+v", resp)
+	}
+}
+
+func TestClient_GeminiEmbeddings(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models/gemini-embedding-001:batchEmbedContents" {
+			t.Errorf("Expected batchEmbedContents, got %s", r.URL.Path)
+		}
+		_, _ = io.WriteString(w, `{"embeddings":[{"values":[0.1,0.2]},{"values":[0.3,0.4]}]}`)
+	}))
+	defer srv.Close()
+
+	client, err := New(
+		WithProvider(Gemini),
+		WithBaseURL(srv.URL),
+		WithToken("key"),
+		WithModel("gemini-2.5-flash"),
+		WithEmbeddingModel("gemini-embedding-001"),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Embed(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(resp.Vectors) != 2 || resp.Vectors[1][1] != 0.4 {
+		t.Errorf("Expected translated embeddings, got %+v", resp)
+	}
+}
+
+func TestClient_GeminiError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = io.WriteString(w, `{"error":{"code":429,"message":"Resource has been exhausted","status":"RESOURCE_EXHAUSTED"}}`)
+	}))
+	defer srv.Close()
+
+	client, err := New(WithProvider(Gemini), WithBaseURL(srv.URL), WithToken("key"), WithModel("gemini-2.5-flash"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	_, err = client.Completion(context.Background(), "", "hi")
+	var apiErr *openaisdk.APIError
+	if !errors.As(err, &apiErr) || apiErr.HTTPStatusCode != http.StatusTooManyRequests || apiErr.Code != "RESOURCE_EXHAUSTED" {
+		t.Errorf("Expected a 429 API error, got: %v", err)
+	}
+}
+
+func TestClient_GeminiListModels(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models" {
+			t.Errorf("Expected /models, got %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("pageToken") == "" {
+			_, _ = io.WriteString(w, `{"models":[{"name":"models/gemini-2.5-flash"}],"nextPageToken":"next"}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"models":[{"name":"models/gemini-embedding-001"}]}`)
+	}))
+	defer srv.Close()
+
+	client, err := New(WithProvider(Gemini), WithBaseURL(srv.URL), WithToken("key"), WithModel("gemini-2.5-flash"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	models, err := client.ListModels(context.Background())
+	if err != nil || len(models) != 2 || models[0].ID != "gemini-2.5-flash" || models[1].ID != "gemini-embedding-001" {
+		t.Errorf("Expected both pages of models without the models/ prefix, got %+v (%v)", models, err)
+	}
+}
@@ -0,0 +1,236 @@
+package openai
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// defaultKeyCooldown is how long a rate-limited key rests when the server gives no hint.
+const defaultKeyCooldown = 30 * time.Second
+
+var errNoUsableKeys = errors.New("all API keys are disabled")
+
+// KeySelection is the strategy a key pool uses to pick the key for the next request.
+type KeySelection int
+
+const (
+	// RoundRobin cycles through the available keys in order.
+	RoundRobin KeySelection = iota
+	// LeastUsed picks the available key with the fewest requests in flight,
+	// then the fewest requests overall.
+	LeastUsed
+)
+
+// KeyStats reports the usage of one API key of a pool.
+type KeyStats struct {
+	// Key is the masked API key.
+	Key         string
+	Requests    int64
+	Failures    int64
+	RateLimited int64
+	// CooldownUntil is when a rate-limited key becomes available again.
+	CooldownUntil time.Time
+	// Disabled is set once the key was rejected as invalid or out of quota.
+	Disabled bool
+}
+
+// keyPool hands out API keys, resting rate-limited keys and retiring rejected ones.
+type keyPool struct {
+	mu        sync.Mutex
+	keys      []*poolKey
+	next      int
+	selection KeySelection
+	cooldown  time.Duration
+}
+
+type poolKey struct {
+	value         string
+	inFlight      int
+	requests      int64
+	failures      int64
+	rateLimited   int64
+	cooldownUntil time.Time
+	disabled      bool
+}
+
+func newKeyPool(keys []string, selection KeySelection, cooldown time.Duration) *keyPool {
+	if cooldown <= 0 {
+		cooldown = defaultKeyCooldown
+	}
+	p := &keyPool{selection: selection, cooldown: cooldown}
+	for _, k := range keys {
+		p.keys = append(p.keys, &poolKey{value: k})
+	}
+	return p
+}
+
+// acquire returns the next usable key. When every key is cooling down it returns
+// how long to wait for the first one to recover instead.
+func (p *keyPool) acquire(now time.Time) (*poolKey, time.Duration, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var (
+		picked *poolKey
+		wait   time.Duration
+		alive  bool
+	)
+	for i := range p.keys {
+		idx := (p.next + i) % len(p.keys)
+		k := p.keys[idx]
+		if k.disabled {
+			continue
+		}
+		alive = true
+		if now.Before(k.cooldownUntil) {
+			if d := k.cooldownUntil.Sub(now); wait == 0 || d < wait {
+				wait = d
+			}
+			continue
+		}
+		if p.selection == RoundRobin {
+			picked = k
+			p.next = idx + 1
+			break
+		}
+		if picked == nil || k.inFlight < picked.inFlight ||
+			(k.inFlight == picked.inFlight && k.requests < picked.requests) {
+			picked = k
+		}
+	}
+
+	if !alive {
+		return nil, 0, errNoUsableKeys
+	}
+	if picked == nil {
+		return nil, wait, nil
+	}
+	picked.inFlight++
+	picked.requests++
+	return picked, 0, nil
+}
+
+// release records the outcome of a request made with the key.
+func (p *keyPool) release(k *poolKey, resp *http.Response, err error) {
+	// Peek at the body before locking, the read may block on the network.
+	quotaExhausted := err == nil && resp.StatusCode == http.StatusTooManyRequests && isQuotaExhausted(resp)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	k.inFlight--
+	if err != nil {
+		k.failures++
+		return
+	}
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		k.failures++
+		k.disabled = true
+	case http.StatusTooManyRequests:
+		k.failures++
+		k.rateLimited++
+		if quotaExhausted {
+			k.disabled = true
+			return
+		}
+		cooldown := p.cooldown
+		if hint, ok := retryAfter(resp.Header, true); ok && hint > 0 {
+			cooldown = hint
+		}
+		k.cooldownUntil = time.Now().Add(cooldown)
+	default:
+		if resp.StatusCode >= http.StatusInternalServerError {
+			k.failures++
+		}
+	}
+}
+
+func (p *keyPool) stats() []KeyStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := make([]KeyStats, 0, len(p.keys))
+	for _, k := range p.keys {
+		stats = append(stats, KeyStats{
+			Key:           maskKey(k.value),
+			Requests:      k.requests,
+			Failures:      k.failures,
+			RateLimited:   k.rateLimited,
+			CooldownUntil: k.cooldownUntil,
+			Disabled:      k.disabled,
+		})
+	}
+	return stats
+}
+
+// keyPoolTransport is an http.RoundTripper that authenticates every request with a key
+// taken from a pool, replacing the credential header set by the underlying client.
+type keyPoolTransport struct {
+	Origin http.RoundTripper
+	pool   *keyPool
+	// header carries the raw key, e.g. api-key for Azure. Empty means a bearer token.
+	header string
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *keyPoolTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	for {
+		k, wait, err := t.pool.acquire(time.Now())
+		if err != nil {
+			return nil, err
+		}
+		if k != nil {
+			r := req.Clone(ctx)
+			if t.header != "" {
+				r.Header.Set(t.header, k.value)
+			} else {
+				r.Header.Set("Authorization", "Bearer "+k.value)
+			}
+			resp, err := t.Origin.RoundTrip(r)
+			t.pool.release(k, resp, err)
+			return resp, err
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// KeyStats returns the usage of every key configured with WithTokens,
+// or nil when the client uses a single key.
+func (c *Client) KeyStats() []KeyStats {
+	if c.keys == nil {
+		return nil
+	}
+	return c.keys.stats()
+}
+
+// maskKey hides all but the edges of a secret so it can be shown in logs and stats.
+func maskKey(key string) string {
+	if len(key) <= 8 {
+		return "****"
+	}
+	return key[:3] + "..." + key[len(key)-4:]
+}
+
+// keyHeader returns the header that carries the API key of a provider, or "" for a bearer token.
+func keyHeader(provider string) string {
+	switch provider {
+	case Azure:
+		return "api-key"
+	case Anthropic:
+		return "x-api-key"
+	case Gemini:
+		return "x-goog-api-key"
+	}
+	return ""
+}
@@ -0,0 +1,181 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newKeyResponse(status int, header http.Header, body string) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{StatusCode: status, Header: header, Body: io.NopCloser(strings.NewReader(body))}
+}
+
+func TestKeyPool_RoundRobin(t *testing.T) {
+	p := newKeyPool([]string{"a", "b", "c"}, RoundRobin, 0)
+
+	var got []string
+	for range 4 {
+		k, _, err := p.acquire(time.Now())
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		got = append(got, k.value)
+		p.release(k, newKeyResponse(http.StatusOK, nil, ""), nil)
+	}
+
+	if strings.Join(got, "") != "abca" {
+		t.Errorf("Expected keys 'abca', got '%s'", strings.Join(got, ""))
+	}
+}
+
+func TestKeyPool_LeastUsed(t *testing.T) {
+	p := newKeyPool([]string{"a", "b"}, LeastUsed, 0)
+
+	// Keep a in flight, so b is picked next.
+	first, _, _ := p.acquire(time.Now())
+	second, _, _ := p.acquire(time.Now())
+	if first.value != "a" || second.value != "b" {
+		t.Errorf("Expected keys (a, b), got (%s, %s)", first.value, second.value)
+	}
+
+	p.release(second, newKeyResponse(http.StatusOK, nil, ""), nil)
+	third, _, _ := p.acquire(time.Now())
+	if third.value != "b" {
+		t.Errorf("Expected idle key 'b', got '%s'", third.value)
+	}
+}
+
+func TestKeyPool_Release(t *testing.T) {
+	tests := []struct {
+		name     string
+		resp     *http.Response
+		cooldown time.Duration
+		disabled bool
+	}{
+		{"Success", newKeyResponse(http.StatusOK, nil, ""), 0, false},
+		{"Rate limited", newKeyResponse(http.StatusTooManyRequests, nil, ""), time.Minute, false},
+		{"Rate limited with hint", newKeyResponse(http.StatusTooManyRequests, http.Header{"Retry-After": {"5"}}, ""), 5 * time.Second, false},
+		{"Quota exhausted", newKeyResponse(http.StatusTooManyRequests, nil, `{"error":{"code":"insufficient_quota"}}`), 0, true},
+		{"Unauthorized", newKeyResponse(http.StatusUnauthorized, nil, ""), 0, true},
+		{"Forbidden", newKeyResponse(http.StatusForbidden, nil, ""), 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newKeyPool([]string{"key"}, RoundRobin, time.Minute)
+			k, _, _ := p.acquire(time.Now())
+			start := time.Now()
+			p.release(k, tt.resp, nil)
+
+			stats := p.stats()[0]
+			if stats.Disabled != tt.disabled {
+				t.Errorf("Expected disabled %v, got %v", tt.disabled, stats.Disabled)
+			}
+
+			var cooldown time.Duration
+			if !stats.CooldownUntil.IsZero() {
+				cooldown = stats.CooldownUntil.Sub(start)
+			}
+			if cooldown < tt.cooldown || cooldown > tt.cooldown+time.Second {
+				t.Errorf("Expected cooldown of %v, got %v", tt.cooldown, cooldown)
+			}
+		})
+	}
+}
+
+func TestKeyPool_AllUnavailable(t *testing.T) {
+	now := time.Now()
+	p := newKeyPool([]string{"a", "b"}, RoundRobin, 0)
+	p.keys[0].cooldownUntil = now.Add(2 * time.Second)
+	p.keys[1].cooldownUntil = now.Add(time.Second)
+
+	k, wait, err := p.acquire(now)
+	if k != nil || err != nil || wait != time.Second {
+		t.Errorf("Expected to wait 1s for the first key, got (%v, %v, %v)", k, wait, err)
+	}
+
+	p.keys[0].disabled = true
+	p.keys[1].disabled = true
+	if _, _, err := p.acquire(now); !errors.Is(err, errNoUsableKeys) {
+		t.Errorf("Expected errNoUsableKeys, got %v", err)
+	}
+}
+
+func TestClient_WithTokens(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		seen []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		mu.Lock()
+		seen = append(seen, auth)
+		mu.Unlock()
+
+		if auth == "Bearer sk-revoked-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = io.WriteString(w, `{"error":{"message":"invalid api key","type":"invalid_request_error"}}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"choices":[{"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`)
+	}))
+	defer server.Close()
+
+	client, err := New(
+		WithTokens([]string{"sk-revoked-key", "sk-working-key"}),
+		WithBaseURL(server.URL),
+		WithRetry(1),
+		WithRetryBackoff(time.Millisecond, time.Millisecond),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	// The revoked key fails once and is never used again.
+	for range 3 {
+		if _, err := client.Completion(context.Background(), "", "hi"); err != nil && !strings.Contains(err.Error(), "401") {
+			t.Fatalf("Expected only the revoked key to fail, got: %v", err)
+		}
+	}
+
+	stats := client.KeyStats()
+	if len(stats) != 2 {
+		t.Fatalf("Expected stats for 2 keys, got %d", len(stats))
+	}
+
+	if !stats[0].Disabled || stats[0].Requests != 1 {
+		t.Errorf("Expected revoked key to be disabled after 1 request, got %+v", stats[0])
+	}
+
+	if stats[1].Disabled || stats[1].Requests == 0 {
+		t.Errorf("Expected working key to serve the requests, got %+v", stats[1])
+	}
+
+	if stats[0].Key != "sk-...-key" {
+		t.Errorf("Expected masked key 'sk-...-key', got '%s'", stats[0].Key)
+	}
+
+	if len(seen) != int(stats[0].Requests+stats[1].Requests) {
+		t.Errorf("Expected %d requests at the server, got %d", stats[0].Requests+stats[1].Requests, len(seen))
+	}
+}
+
+func TestClient_KeyStatsSingleToken(t *testing.T) {
+	client, err := New(WithToken("test-token"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	if stats := client.KeyStats(); stats != nil {
+		t.Errorf("Expected no key stats for a single token, got %v", stats)
+	}
+}
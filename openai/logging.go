@@ -0,0 +1,189 @@
+package openai
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// redacted replaces credentials in logged headers and URLs.
+const redacted = "****"
+
+// credentialHints are fragments of header and query parameter names that carry secrets.
+var credentialHints = []string{"auth", "key", "token", "secret", "password", "cookie", "signature", "session"}
+
+// LoggingTransport is an http.RoundTripper that logs every request attempt with its
+// latency, status and token usage. Credentials are always redacted: the bearer token,
+// api-key and any header or query parameter whose name looks like a secret.
+type LoggingTransport struct {
+	Origin http.RoundTripper
+	Logger *slog.Logger
+	// Level is the level of successful requests. Error responses are logged at
+	// slog.LevelWarn and transport failures at slog.LevelError, unless Level is higher.
+	Level slog.Level
+	// MaxBodySize, if positive, logs request and response bodies truncated to this many bytes.
+	// Streamed responses are never read by the transport and their body is not logged.
+	MaxBodySize int
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *LoggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if !t.Logger.Enabled(ctx, max(t.Level, slog.LevelError)) {
+		return t.Origin.RoundTrip(req)
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("url", redactURL(req.URL)),
+		slog.Any("headers", redactHeaders(req.Header)),
+	}
+	if t.MaxBodySize > 0 {
+		if body, ok := requestBody(req); ok {
+			attrs = append(attrs, slog.String("request_body", truncate(body, t.MaxBodySize)))
+		}
+	}
+
+	start := time.Now()
+	resp, err := t.Origin.RoundTrip(req)
+	attrs = append(attrs, slog.Duration("latency", time.Since(start)))
+
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+		t.Logger.LogAttrs(ctx, max(t.Level, slog.LevelError), "openai request failed", attrs...)
+		return resp, err
+	}
+
+	attrs = append(attrs, slog.Int("status", resp.StatusCode))
+	if id := requestID(resp.Header); id != "" {
+		attrs = append(attrs, slog.String("request_id", id))
+	}
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		attrs = append(attrs, t.responseAttrs(resp)...)
+	}
+
+	level := t.Level
+	if resp.StatusCode >= http.StatusBadRequest {
+		level = max(level, slog.LevelWarn)
+	}
+	t.Logger.LogAttrs(ctx, level, "openai request", attrs...)
+	return resp, nil
+}
+
+// responseAttrs buffers a non-streamed response body to report its usage and, if enabled, its content.
+func (t *LoggingTransport) responseAttrs(resp *http.Response) []slog.Attr {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return []slog.Attr{slog.String("error", err.Error())}
+	}
+
+	var attrs []slog.Attr
+	var payload struct {
+		Usage *struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+			TotalTokens      int `json:"total_tokens"`
+		} `json:"usage"`
+	}
+	if json.Unmarshal(body, &payload) == nil && payload.Usage != nil {
+		attrs = append(attrs, slog.Group("usage",
+			slog.Int("prompt_tokens", payload.Usage.PromptTokens),
+			slog.Int("completion_tokens", payload.Usage.CompletionTokens),
+			slog.Int("total_tokens", payload.Usage.TotalTokens),
+		))
+	}
+	if t.MaxBodySize > 0 {
+		attrs = append(attrs, slog.String("response_body", truncate(body, t.MaxBodySize)))
+	}
+	return attrs
+}
+
+// requestBody returns a copy of the request body without consuming it.
+func requestBody(req *http.Request) ([]byte, bool) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, false
+	}
+	if req.GetBody != nil {
+		rc, err := req.GetBody()
+		if err != nil {
+			return nil, false
+		}
+		defer rc.Close()
+		body, err := io.ReadAll(rc)
+		return body, err == nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, err == nil
+}
+
+func truncate(body []byte, size int) string {
+	if len(body) <= size {
+		return string(body)
+	}
+	return string(body[:size]) + "...(" + strconv.Itoa(len(body)) + " bytes)"
+}
+
+// isCredential reports whether a header or query parameter name looks like it carries a secret.
+func isCredential(name string) bool {
+	name = strings.ToLower(name)
+	for _, hint := range credentialHints {
+		if strings.Contains(name, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactSecret replaces a credential, keeping the authentication scheme if any.
+// Unlike maskKey, no part of the secret is kept.
+func redactSecret(value string) string {
+	if scheme, _, ok := strings.Cut(value, " "); ok {
+		return scheme + " " + redacted
+	}
+	return redacted
+}
+
+// redactHeaders returns a copy of the headers with credential values masked.
+func redactHeaders(h http.Header) http.Header {
+	out := h.Clone()
+	for name, values := range out {
+		if !isCredential(name) {
+			continue
+		}
+		for i, v := range values {
+			values[i] = redactSecret(v)
+		}
+	}
+	return out
+}
+
+// redactURL returns the URL with credential query parameters masked.
+func redactURL(u *url.URL) string {
+	query := u.Query()
+	changed := false
+	for name, values := range query {
+		if !isCredential(name) {
+			continue
+		}
+		for i := range values {
+			values[i] = redacted
+		}
+		changed = true
+	}
+	if !changed {
+		return u.String()
+	}
+	r := *u
+	r.RawQuery = query.Encode()
+	return r.String()
+}
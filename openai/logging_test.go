@@ -0,0 +1,142 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func decodeLogs(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	for line := range strings.SplitSeq(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Failed to decode log line %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestClient_WithLogger(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-Request-Id", "req-123")
+		_, _ = io.WriteString(w, `{"choices":[{"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}],"usage":{"prompt_tokens":7,"completion_tokens":2,"total_tokens":9}}`)
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	client, err := New(
+		WithToken("sk-secret-token-value"),
+		WithBaseURL(srv.URL),
+		WithHeaders([]string{"X-Tenant=acme", "X-Gateway-Token=gw-secret-value"}),
+		WithLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))),
+		WithLogBodies(16),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Completion(context.Background(), "", "hello")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if resp.Content != "ok" {
+		t.Errorf("Expected the logged response to reach the caller, got '%s'", resp.Content)
+	}
+
+	logs := buf.String()
+	for _, secret := range []string{"sk-secret-token-value", "gw-secret-value"} {
+		if strings.Contains(logs, secret) {
+			t.Errorf("Expected %s to be redacted, got %s", secret, logs)
+		}
+	}
+
+	records := decodeLogs(t, &buf)
+	if len(records) != 1 {
+		t.Fatalf("Expected 1 log record, got %d", len(records))
+	}
+	record := records[0]
+
+	if record["level"] != "DEBUG" || record["status"] != float64(200) || record["request_id"] != "req-123" {
+		t.Errorf("Expected a debug record with status and request ID, got %v", record)
+	}
+
+	headers, _ := record["headers"].(map[string]any)
+	if auth := headers["Authorization"]; auth == nil || auth.([]any)[0].(string) != "Bearer ****" {
+		t.Errorf("Expected a masked bearer token, got %v", auth)
+	}
+	if token := headers["X-Gateway-Token"]; token == nil || token.([]any)[0] != "****" {
+		t.Errorf("Expected the custom credential header to be fully redacted, got %v", token)
+	}
+	if tenant := headers["X-Tenant"]; tenant == nil || tenant.([]any)[0] != "acme" {
+		t.Errorf("Expected non-credential headers to be logged as is, got %v", tenant)
+	}
+
+	if usage, _ := record["usage"].(map[string]any); usage["total_tokens"] != float64(9) {
+		t.Errorf("Expected usage in the log record, got %v", record["usage"])
+	}
+
+	if body, _ := record["response_body"].(string); !strings.HasPrefix(body, `{"choices":[{"me...(`) {
+		t.Errorf("Expected a truncated response body, got %q", body)
+	}
+}
+
+func TestClient_WithLoggerFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = io.WriteString(w, `{"error":{"message":"bad key"}}`)
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	client, err := New(
+		WithToken("test-token"),
+		WithBaseURL(srv.URL),
+		// Successful requests are not logged at info level.
+		WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	if _, err := client.Completion(context.Background(), "", "hello"); err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+
+	records := decodeLogs(t, &buf)
+	if len(records) != 1 || records[0]["level"] != "WARN" || records[0]["status"] != float64(401) {
+		t.Errorf("Expected a warning with status 401, got %v", records)
+	}
+	if _, ok := records[0]["response_body"]; ok {
+		t.Error("Expected no body without WithLogBodies")
+	}
+}
+
+func TestRedactURL(t *testing.T) {
+	tests := []struct {
+		raw      string
+		expected string
+	}{
+		{"https://example.com/v1/chat?api-version=2024-06-01", "https://example.com/v1/chat?api-version=2024-06-01"},
+		{"https://example.com/v1/models?key=AIzaSecret", "https://example.com/v1/models?key=%2A%2A%2A%2A"},
+	}
+
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.raw)
+		if got := redactURL(u); got != tt.expected {
+			t.Errorf("Expected %s, got %s", tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,432 @@
+package openai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// defaultOllamaURL is the address of a local Ollama server.
+const defaultOllamaURL = "http://localhost:11434"
+
+// OllamaOptions are settings of the native Ollama API, see WithOllamaNative.
+type OllamaOptions struct {
+	// NumCtx is the context window size in tokens. Zero uses the model default.
+	NumCtx int
+	// KeepAlive is how long the model stays loaded after the request. Zero uses the
+	// server default and a negative value keeps the model loaded indefinitely.
+	KeepAlive time.Duration
+	// Options are other model parameters, e.g. num_gpu, seed or repeat_penalty.
+	// They take precedence over the sampling parameters of the client.
+	Options map[string]any
+}
+
+// ollamaBaseURL returns the root URL of the Ollama server, without the /v1 suffix
+// of its OpenAI-compatible endpoint.
+func ollamaBaseURL(baseURL string) string {
+	if baseURL == "" {
+		return defaultOllamaURL
+	}
+	baseURL = strings.TrimRight(baseURL, "/")
+	return strings.TrimSuffix(baseURL, "/v1")
+}
+
+// ollamaBackend speaks the native Ollama API: /api/chat and /api/embed.
+type ollamaBackend struct {
+	baseURL string
+	// token, if set, is sent as a bearer token for servers behind an authenticating proxy.
+	token  string
+	client *http.Client
+	// images downloads remote images without the credentials and headers of client.
+	images  *http.Client
+	options OllamaOptions
+}
+
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	Thinking  string           `json:"thinking,omitempty"`
+	Images    []string         `json:"images,omitempty"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"`
+}
+
+type ollamaToolCall struct {
+	Function struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"function"`
+}
+
+type ollamaChatRequest struct {
+	Model     string          `json:"model"`
+	Messages  []ollamaMessage `json:"messages"`
+	Tools     []openai.Tool   `json:"tools,omitempty"`
+	Format    json.RawMessage `json:"format,omitempty"`
+	Options   map[string]any  `json:"options,omitempty"`
+	Stream    bool            `json:"stream"`
+	KeepAlive any             `json:"keep_alive,omitempty"`
+}
+
+type ollamaChatResponse struct {
+	Model           string        `json:"model"`
+	CreatedAt       time.Time     `json:"created_at"`
+	Message         ollamaMessage `json:"message"`
+	Done            bool          `json:"done"`
+	DoneReason      string        `json:"done_reason"`
+	PromptEvalCount int           `json:"prompt_eval_count"`
+	EvalCount       int           `json:"eval_count"`
+}
+
+func (b *ollamaBackend) CreateChatCompletion(
+	ctx context.Context,
+	req openai.ChatCompletionRequest,
+) (openai.ChatCompletionResponse, error) {
+	body, err := b.chatRequest(ctx, req, false)
+	if err != nil {
+		return openai.ChatCompletionResponse{}, err
+	}
+	resp, err := b.post(ctx, "/api/chat", body)
+	if err != nil {
+		return openai.ChatCompletionResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	var r ollamaChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return openai.ChatCompletionResponse{}, fmt.Errorf("ollama: invalid response: %w", err)
+	}
+
+	toolCalls := r.Message.openaiToolCalls()
+	return openai.ChatCompletionResponse{
+		Object:  "chat.completion",
+		Created: r.CreatedAt.Unix(),
+		Model:   r.Model,
+		Choices: []openai.ChatCompletionChoice{{
+			Message: openai.ChatCompletionMessage{
+				Role:             openai.ChatMessageRoleAssistant,
+				Content:          r.Message.Content,
+				ReasoningContent: r.Message.Thinking,
+				ToolCalls:        toolCalls,
+			},
+			FinishReason: ollamaFinishReason(r.DoneReason, len(toolCalls) > 0),
+		}},
+		Usage: r.usage(),
+	}, nil
+}
+
+func (b *ollamaBackend) CreateChatCompletionStream(
+	ctx context.Context,
+	req openai.ChatCompletionRequest,
+) (ChatCompletionStream, error) {
+	body, err := b.chatRequest(ctx, req, true)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := b.post(ctx, "/api/chat", body)
+	if err != nil {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64<<10), 16<<20)
+	return &ollamaStream{body: resp.Body, scanner: scanner}, nil
+}
+
+func (b *ollamaBackend) CreateEmbeddings(
+	ctx context.Context,
+	conv openai.EmbeddingRequestConverter,
+) (openai.EmbeddingResponse, error) {
+	req := conv.Convert()
+	body, err := json.Marshal(struct {
+		Model      string `json:"model"`
+		Input      any    `json:"input"`
+		Dimensions int    `json:"dimensions,omitempty"`
+	}{string(req.Model), req.Input, req.Dimensions})
+	if err != nil {
+		return openai.EmbeddingResponse{}, err
+	}
+	resp, err := b.post(ctx, "/api/embed", body)
+	if err != nil {
+		return openai.EmbeddingResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	var r struct {
+		Model           string      `json:"model"`
+		Embeddings      [][]float32 `json:"embeddings"`
+		PromptEvalCount int         `json:"prompt_eval_count"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return openai.EmbeddingResponse{}, fmt.Errorf("ollama: invalid response: %w", err)
+	}
+
+	out := openai.EmbeddingResponse{
+		Object: "list",
+		Model:  openai.EmbeddingModel(r.Model),
+		Usage:  openai.Usage{PromptTokens: r.PromptEvalCount, TotalTokens: r.PromptEvalCount},
+	}
+	for i, vec := range r.Embeddings {
+		out.Data = append(out.Data, openai.Embedding{Object: "embedding", Index: i, Embedding: vec})
+	}
+	return out, nil
+}
+
+// chatRequest translates an OpenAI chat completion request to the native API.
+func (b *ollamaBackend) chatRequest(ctx context.Context, req openai.ChatCompletionRequest, stream bool) ([]byte, error) {
+	r := ollamaChatRequest{
+		Model:  req.Model,
+		Tools:  req.Tools,
+		Stream: stream,
+		Options: map[string]any{
+			"temperature": req.Temperature,
+			"top_p":       req.TopP,
+		},
+	}
+	if req.PresencePenalty != 0 {
+		r.Options["presence_penalty"] = req.PresencePenalty
+	}
+	if req.FrequencyPenalty != 0 {
+		r.Options["frequency_penalty"] = req.FrequencyPenalty
+	}
+	if req.MaxTokens > 0 {
+		r.Options["num_predict"] = req.MaxTokens
+	}
+	if len(req.Stop) > 0 {
+		r.Options["stop"] = req.Stop
+	}
+	if req.Seed != nil {
+		r.Options["seed"] = *req.Seed
+	}
+	if b.options.NumCtx > 0 {
+		r.Options["num_ctx"] = b.options.NumCtx
+	}
+	for k, v := range b.options.Options {
+		r.Options[k] = v
+	}
+	switch {
+	case b.options.KeepAlive < 0:
+		r.KeepAlive = -1
+	case b.options.KeepAlive > 0:
+		r.KeepAlive = b.options.KeepAlive.String()
+	}
+
+	if f := req.ResponseFormat; f != nil {
+		switch {
+		case f.Type == openai.ChatCompletionResponseFormatTypeJSONSchema && f.JSONSchema != nil:
+			schema, err := json.Marshal(f.JSONSchema.Schema)
+			if err != nil {
+				return nil, err
+			}
+			r.Format = schema
+		case f.Type == openai.ChatCompletionResponseFormatTypeJSONObject:
+			r.Format = json.RawMessage(`"json"`)
+		}
+	}
+
+	// Tool results are matched to the function that was called by its call ID.
+	toolNames := make(map[string]string)
+	for _, m := range req.Messages {
+		for _, call := range m.ToolCalls {
+			toolNames[call.ID] = call.Function.Name
+		}
+
+		msg := ollamaMessage{Role: m.Role, Content: m.Content}
+		for _, part := range m.MultiContent {
+			switch part.Type {
+			case openai.ChatMessagePartTypeText:
+				msg.Content += part.Text
+			case openai.ChatMessagePartTypeImageURL:
+				if part.ImageURL == nil {
+					continue
+				}
+				img, err := b.image(ctx, part.ImageURL.URL)
+				if err != nil {
+					return nil, err
+				}
+				msg.Images = append(msg.Images, img)
+			}
+		}
+		for _, call := range m.ToolCalls {
+			var tc ollamaToolCall
+			tc.Function.Name = call.Function.Name
+			tc.Function.Arguments = json.RawMessage(call.Function.Arguments)
+			if !json.Valid(tc.Function.Arguments) {
+				tc.Function.Arguments = json.RawMessage("{}")
+			}
+			msg.ToolCalls = append(msg.ToolCalls, tc)
+		}
+		if m.Role == openai.ChatMessageRoleTool {
+			msg.ToolName = m.Name
+			if msg.ToolName == "" {
+				msg.ToolName = toolNames[m.ToolCallID]
+			}
+		}
+		r.Messages = append(r.Messages, msg)
+	}
+	return json.Marshal(r)
+}
+
+// image returns the base64 bytes of an image, downloading remote URLs
+// since the native API only accepts inline images.
+func (b *ollamaBackend) image(ctx context.Context, rawURL string) (string, error) {
+	_, data, err := inlineImage(ctx, b.images, rawURL)
+	if err != nil {
+		return "", fmt.Errorf("ollama: %w", err)
+	}
+	return data, nil
+}
+
+// ListModels returns the models pulled to the server.
+func (b *ollamaBackend) ListModels(ctx context.Context) (openai.ModelsList, error) {
+	var r struct {
+		Models []struct {
+			Name       string    `json:"name"`
+			ModifiedAt time.Time `json:"modified_at"`
+		} `json:"models"`
+	}
+	if err := getJSON(ctx, b.client, b.baseURL+"/api/tags", b.header(), &r, parseOllamaError); err != nil {
+		return openai.ModelsList{}, fmt.Errorf("ollama: %w", err)
+	}
+
+	var list openai.ModelsList
+	for _, m := range r.Models {
+		list.Models = append(list.Models, openai.Model{
+			ID:        m.Name,
+			Object:    "model",
+			CreatedAt: m.ModifiedAt.Unix(),
+			OwnedBy:   "library",
+		})
+	}
+	return list, nil
+}
+
+// post sends a request to the native API, turning error responses into *openai.APIError.
+func (b *ollamaBackend) post(ctx context.Context, path string, body []byte) (*http.Response, error) {
+	return postJSON(ctx, b.client, b.baseURL+path, b.header(), body, parseOllamaError)
+}
+
+func (b *ollamaBackend) header() http.Header {
+	header := make(http.Header)
+	if b.token != "" {
+		header.Set("Authorization", "Bearer "+b.token)
+	}
+	return header
+}
+
+func parseOllamaError(status int, body []byte) error {
+	var e struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(body, &e) != nil || e.Error == "" {
+		e.Error = strings.TrimSpace(string(body))
+	}
+	return &openai.APIError{
+		HTTPStatusCode: status,
+		Message:        e.Error,
+		Type:           "ollama_error",
+	}
+}
+
+func (m ollamaMessage) openaiToolCalls() []openai.ToolCall {
+	var calls []openai.ToolCall
+	for i, tc := range m.ToolCalls {
+		index := i
+		calls = append(calls, openai.ToolCall{
+			Index: &index,
+			ID:    fmt.Sprintf("call_%d", i),
+			Type:  openai.ToolTypeFunction,
+			Function: openai.FunctionCall{
+				Name:      tc.Function.Name,
+				Arguments: string(tc.Function.Arguments),
+			},
+		})
+	}
+	return calls
+}
+
+func (r ollamaChatResponse) usage() openai.Usage {
+	return openai.Usage{
+		PromptTokens:     r.PromptEvalCount,
+		CompletionTokens: r.EvalCount,
+		TotalTokens:      r.PromptEvalCount + r.EvalCount,
+	}
+}
+
+func ollamaFinishReason(reason string, toolCalls bool) openai.FinishReason {
+	switch {
+	case toolCalls:
+		return openai.FinishReasonToolCalls
+	case reason == "length":
+		return openai.FinishReasonLength
+	}
+	return openai.FinishReasonStop
+}
+
+// ollamaStream reads the newline-delimited JSON chunks of a streamed chat.
+type ollamaStream struct {
+	body    io.ReadCloser
+	scanner *bufio.Scanner
+	done    bool
+	// toolCalls reports whether a chunk called a tool, which sets the final finish reason.
+	toolCalls bool
+}
+
+func (s *ollamaStream) Recv() (openai.ChatCompletionStreamResponse, error) {
+	for !s.done && s.scanner.Scan() {
+		line := bytes.TrimSpace(s.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var r ollamaChatResponse
+		var e struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(line, &e) == nil && e.Error != "" {
+			return openai.ChatCompletionStreamResponse{}, &openai.APIError{Message: e.Error, Type: "ollama_error"}
+		}
+		if err := json.Unmarshal(line, &r); err != nil {
+			return openai.ChatCompletionStreamResponse{}, fmt.Errorf("ollama: invalid stream chunk: %w", err)
+		}
+
+		chunk := openai.ChatCompletionStreamResponse{
+			Object:  "chat.completion.chunk",
+			Created: r.CreatedAt.Unix(),
+			Model:   r.Model,
+		}
+		toolCalls := r.Message.openaiToolCalls()
+		choice := openai.ChatCompletionStreamChoice{
+			Delta: openai.ChatCompletionStreamChoiceDelta{
+				Role:             r.Message.Role,
+				Content:          r.Message.Content,
+				ReasoningContent: r.Message.Thinking,
+				ToolCalls:        toolCalls,
+			},
+		}
+		s.toolCalls = s.toolCalls || len(toolCalls) > 0
+		if r.Done {
+			s.done = true
+			choice.FinishReason = ollamaFinishReason(r.DoneReason, s.toolCalls)
+			usage := r.usage()
+			chunk.Usage = &usage
+		}
+		chunk.Choices = []openai.ChatCompletionStreamChoice{choice}
+		return chunk, nil
+	}
+	if err := s.scanner.Err(); err != nil {
+		return openai.ChatCompletionStreamResponse{}, err
+	}
+	return openai.ChatCompletionStreamResponse{}, io.EOF
+}
+
+func (s *ollamaStream) Close() error {
+	return s.body.Close()
+}
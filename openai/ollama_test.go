@@ -0,0 +1,242 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	openaisdk "github.com/sashabaranov/go-openai"
+)
+
+func TestNew_Ollama(t *testing.T) {
+	if _, err := New(WithProvider(Ollama)); !errors.Is(err, errorsMissingModel) {
+		t.Errorf("Expected missing model error, got: %v", err)
+	}
+
+	client, err := New(WithProvider(Ollama), WithModel("llama3.2"))
+	if err != nil {
+		t.Fatalf("Expected no token to be required, got: %v", err)
+	}
+	if client.Name() != Ollama {
+		t.Errorf("Expected name '%s', got '%s'", Ollama, client.Name())
+	}
+
+	tests := []struct {
+		name     string
+		cfg      *config
+		expected string
+	}{
+		{"Default", &config{provider: Ollama}, "http://localhost:11434/v1"},
+		{"Native", &config{provider: Ollama, ollamaNative: true}, "http://localhost:11434"},
+		{"Custom", &config{provider: Ollama, baseURL: "http://gpu:11434/"}, "http://gpu:11434/v1"},
+		{"Custom native with /v1", &config{provider: Ollama, baseURL: "http://gpu:11434/v1", ollamaNative: true}, "http://gpu:11434"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.endpoint(); got != tt.expected {
+				t.Errorf("Expected endpoint %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestClient_OllamaCompatible(t *testing.T) {
+	var path string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		_, _ = io.WriteString(w, `{"choices":[{"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`)
+	}))
+	defer srv.Close()
+
+	client, err := New(WithProvider(Ollama), WithBaseURL(srv.URL), WithModel("llama3.2"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if _, err := client.Completion(context.Background(), "", "hi"); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if path != "/v1/chat/completions" {
+		t.Errorf("Expected the OpenAI-compatible endpoint, got %s", path)
+	}
+}
+
+func TestClient_OllamaNative(t *testing.T) {
+	var sent map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("Expected /api/chat, got %s", r.URL.Path)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&sent)
+		_, _ = io.WriteString(w, `{"model":"llava","created_at":"2025-01-01T00:00:00Z","message":{"role":"assistant","content":"A boardwalk."},"done":true,"done_reason":"stop","prompt_eval_count":20,"eval_count":4}`)
+	}))
+	defer srv.Close()
+
+	client, err := New(
+		WithProvider(Ollama),
+		WithBaseURL(srv.URL),
+		WithModel("llava"),
+		WithOllamaNative(OllamaOptions{NumCtx: 8192, KeepAlive: 10 * time.Minute, Options: map[string]any{"num_gpu": 1}}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.ImageCompletion(context.Background(), "data:image/png;base64,iVBORw0KGgo=", "", "Describe")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if resp.Content != "A boardwalk." || resp.Usage.TotalTokens != 24 || resp.FinishReason != openaisdk.FinishReasonStop {
+		t.Errorf("Expected translated response, got %+v", resp)
+	}
+
+	options, _ := sent["options"].(map[string]any)
+	if options["num_ctx"] != float64(8192) || options["num_gpu"] != float64(1) || sent["keep_alive"] != "10m0s" {
+		t.Errorf("Expected native options, got %v", sent)
+	}
+	messages, _ := sent["messages"].([]any)
+	msg, _ := messages[0].(map[string]any)
+	if images, _ := msg["images"].([]any); len(images) != 1 || images[0] != "iVBORw0KGgo=" || msg["content"] != "Describe" {
+		t.Errorf("Expected inline image bytes, got %v", msg)
+	}
+}
+
+func TestClient_OllamaNativeStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		for _, line := range []string{
+			`{"model":"llama3.2","message":{"role":"assistant","content":"Hello"},"done":false}`,
+			`{"model":"llama3.2","message":{"role":"assistant","content":", world"},"done":false}`,
+			`{"model":"llama3.2","message":{"role":"assistant","content":""},"done":true,"done_reason":"stop","prompt_eval_count":5,"eval_count":3}`,
+		} {
+			_, _ = io.WriteString(w, line+"\n")
+		}
+	}))
+	defer srv.Close()
+
+	client, err := New(WithProvider(Ollama), WithBaseURL(srv.URL), WithModel("llama3.2"), WithOllamaNative(OllamaOptions{}))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	stream, err := client.CompletionStream(context.Background(), "", "hi")
+	if err != nil {
+		t.Fatalf("CompletionStream failed: %v", err)
+	}
+	defer stream.Close()
+
+	resp, err := stream.Response()
+	if err != nil {
+		t.Fatalf("Response failed: %v", err)
+	}
+	if resp.Content != "Hello, world" || resp.Usage.TotalTokens != 8 || resp.FinishReason != openaisdk.FinishReasonStop {
+		t.Errorf("Expected assembled stream, got %+v", resp)
+	}
+}
+
+func TestClient_OllamaNativeEmbeddings(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/embed" {
+			t.Errorf("Expected /api/embed, got %s", r.URL.Path)
+		}
+		_, _ = io.WriteString(w, `{"model":"nomic-embed-text","embeddings":[[0.1,0.2],[0.3,0.4]],"prompt_eval_count":4}`)
+	}))
+	defer srv.Close()
+
+	client, err := New(
+		WithProvider(Ollama),
+		WithBaseURL(srv.URL),
+		WithModel("llama3.2"),
+		WithEmbeddingModel("nomic-embed-text"),
+		WithOllamaNative(OllamaOptions{}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Embed(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(resp.Vectors) != 2 || resp.Vectors[1][1] != 0.4 || resp.Usage.PromptTokens != 4 {
+		t.Errorf("Expected translated embeddings, got %+v", resp)
+	}
+}
+
+func TestClient_OllamaNativeError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = io.WriteString(w, `{"error":"model \"missing\" not found, try pulling it first"}`)
+	}))
+	defer srv.Close()
+
+	client, err := New(WithProvider(Ollama), WithBaseURL(srv.URL), WithModel("missing"), WithOllamaNative(OllamaOptions{}))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	_, err = client.Completion(context.Background(), "", "hi")
+	var apiErr *openaisdk.APIError
+	if !errors.As(err, &apiErr) || apiErr.HTTPStatusCode != http.StatusNotFound || !strings.Contains(apiErr.Message, "not found") {
+		t.Errorf("Expected a 404 API error, got: %v", err)
+	}
+}
+
+func TestOllamaBackend_ToolMessages(t *testing.T) {
+	b := &ollamaBackend{}
+	body, err := b.chatRequest(context.Background(), openaisdk.ChatCompletionRequest{
+		Model: "llama3.2",
+		Messages: []openaisdk.ChatCompletionMessage{
+			{Role: openaisdk.ChatMessageRoleUser, Content: "Weather?"},
+			{Role: openaisdk.ChatMessageRoleAssistant, ToolCalls: []openaisdk.ToolCall{{
+				ID:       "call_0",
+				Type:     openaisdk.ToolTypeFunction,
+				Function: openaisdk.FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`},
+			}}},
+			{Role: openaisdk.ChatMessageRoleTool, ToolCallID: "call_0", Content: "sunny"},
+		},
+		ResponseFormat: &openaisdk.ChatCompletionResponseFormat{Type: openaisdk.ChatCompletionResponseFormatTypeJSONObject},
+	}, false)
+	if err != nil {
+		t.Fatalf("Failed to build request: %v", err)
+	}
+
+	var r ollamaChatRequest
+	if err := json.Unmarshal(body, &r); err != nil {
+		t.Fatalf("Failed to decode request: %v", err)
+	}
+	if string(r.Messages[1].ToolCalls[0].Function.Arguments) != `{"city":"Paris"}` {
+		t.Errorf("Expected arguments as a JSON object, got %s", r.Messages[1].ToolCalls[0].Function.Arguments)
+	}
+	if r.Messages[2].ToolName != "get_weather" {
+		t.Errorf("Expected tool name from the call ID, got '%s'", r.Messages[2].ToolName)
+	}
+	if string(r.Format) != `"json"` {
+		t.Errorf("Expected json format, got %s", r.Format)
+	}
+}
+
+func TestClient_OllamaNativeListModels(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/tags" {
+			t.Errorf("Expected /api/tags, got %s", r.URL.Path)
+		}
+		_, _ = io.WriteString(w, `{"models":[{"name":"llama3.2:latest","modified_at":"2025-01-01T00:00:00Z"},{"name":"llava:7b","modified_at":"2025-01-02T00:00:00Z"}]}`)
+	}))
+	defer srv.Close()
+
+	client, err := New(WithProvider(Ollama), WithBaseURL(srv.URL), WithModel("llama3.2"), WithOllamaNative(OllamaOptions{}))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	models, err := client.ListModels(context.Background())
+	if err != nil || len(models) != 2 || models[1].ID != "llava:7b" {
+		t.Errorf("Expected the pulled models, got %+v (%v)", models, err)
+	}
+}
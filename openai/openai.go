@@ -7,8 +7,11 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
 
 	openai "github.com/sashabaranov/go-openai"
+	"go.opentelemetry.io/otel/propagation"
+	"golang.org/x/net/http/httpproxy"
 	"golang.org/x/net/proxy"
 )
 
@@ -24,7 +27,8 @@ var DefaultModel = openai.GPT4oMini
 
 // Client is a struct that represents an OpenAI client.
 type Client struct {
-	client      *openai.Client
+	client      Backend
+	name        string
 	model       string
 	temperature float32
 
@@ -40,11 +44,37 @@ type Client struct {
 	// Positive values penalize new tokens based on their existing frequency in the text so far,
 	// decreasing the model's likelihood to repeat the same line verbatim.
 	frequencyPenalty float32
+
+	tools             *toolRegistry
+	maxToolIterations int
+
+	// jsonSchema reports whether structured output uses response_format json_schema.
+	jsonSchema bool
+	// streamUsage reports whether streams ask for a trailing usage chunk with stream_options.
+	streamUsage bool
+
+	limiter *rateLimiter
+	keys    *keyPool
+
+	cache               Cache
+	cacheMaxTemperature float32
+
+	embeddingModel       openai.EmbeddingModel
+	embeddingBatchSize   int
+	embeddingConcurrency int
+
+	usage     *UsageTracker
+	telemetry *telemetry
 }
 
 type Response struct {
-	Content string
-	Usage   openai.Usage
+	Content      string
+	Usage        openai.Usage
+	FinishReason openai.FinishReason
+	// Provider is the name of the client that served the response.
+	Provider string
+	// CacheHit reports whether the response was served from the cache set with WithCache.
+	CacheHit bool
 }
 
 // New creates a new OpenAI API client with the given options.
@@ -57,10 +87,9 @@ func New(opts ...Option) (*Client, error) {
 		return nil, err
 	}
 
-	// Create a new client instance with the necessary fields.
-	engine := &Client{
-		model:       cfg.model,
-		temperature: cfg.temperature,
+	engine, err := newClient(cfg)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create a new OpenAI config object with the given API token and other optional fields.
@@ -87,35 +116,106 @@ func New(opts ...Option) (*Client, error) {
 		Timeout: cfg.timeout,
 	}
 
-	if cfg.proxyURL != "" {
+	if _, fromEnv := cfg.envSources["proxy_url"]; fromEnv {
+		tr.Proxy = envProxy(cfg.proxyURL, cfg.noProxy)
+	} else if cfg.proxyURL != "" {
 		proxyURL, _ := url.Parse(cfg.proxyURL)
 		tr.Proxy = http.ProxyURL(proxyURL)
 	} else if cfg.socksURL != "" {
-		dialer, err := proxy.SOCKS5("tcp", cfg.socksURL, nil, proxy.Direct)
+		dialer, err := socksDialer(cfg.socksURL)
 		if err != nil {
 			return nil, fmt.Errorf("proxy connection failed: verify SOCKS5 proxy address and network connectivity")
 		}
+		if cfg.noProxy != "" {
+			perHost := proxy.NewPerHost(dialer, proxy.Direct)
+			perHost.AddFromString(cfg.noProxy)
+			dialer = perHost
+		}
 		tr.DialContext = dialer.(proxy.ContextDialer).DialContext
 	}
 
 	// Set the HTTP client to use the default header transport with the specified headers.
+	var base http.RoundTripper = tr
+	if cfg.transport != nil {
+		base = cfg.transport
+	}
+	origin := base
+	if cfg.logger != nil {
+		origin = &LoggingTransport{
+			Origin:      origin,
+			Logger:      cfg.logger,
+			Level:       cfg.logLevel,
+			MaxBodySize: cfg.logBodySize,
+		}
+	}
+	if len(cfg.tokens) > 0 {
+		engine.keys = newKeyPool(cfg.tokens, cfg.keySelection, cfg.keyCooldown)
+		origin = &keyPoolTransport{Origin: origin, pool: engine.keys, header: keyHeader(cfg.provider)}
+	}
+	propagator := cfg.propagator
+	if propagator == nil && cfg.tracerProvider != nil {
+		propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+	}
 	httpClient.Transport = &DefaultHeaderTransport{
-		Origin: tr,
-		Header: NewHeaders(cfg.headers),
+		Origin:     origin,
+		Header:     NewHeaders(cfg.headers),
+		Propagator: propagator,
+	}
+	if cfg.maxRetries > 0 {
+		httpClient.Transport = &RetryTransport{
+			Origin:     httpClient.Transport,
+			MaxRetries: cfg.maxRetries,
+			BaseDelay:  cfg.retryBaseDelay,
+			MaxDelay:   cfg.retryMaxDelay,
+		}
 	}
 
 	switch cfg.provider {
+	case Ollama:
+		if cfg.ollamaNative {
+			engine.client = &ollamaBackend{
+				baseURL: cfg.endpoint(),
+				token:   cfg.token,
+				client:  httpClient,
+				images:  &http.Client{Transport: base, Timeout: cfg.timeout},
+				options: cfg.ollamaOptions,
+			}
+			break
+		}
+		c.BaseURL = cfg.endpoint()
+		c.HTTPClient = httpClient
+		engine.client = openaiBackend{openai.NewClientWithConfig(c)}
+
+	case Anthropic:
+		engine.client = &anthropicBackend{
+			baseURL: cfg.endpoint(),
+			token:   cfg.token,
+			client:  httpClient,
+		}
+
+	case Gemini:
+		engine.client = &geminiBackend{
+			baseURL: cfg.endpoint(),
+			token:   cfg.token,
+			client:  httpClient,
+			images:  &http.Client{Transport: base, Timeout: cfg.timeout},
+		}
+
 	case Azure:
 		// Azure OpenAI has special configuration requirements
 		defaultAzureConfig := openai.DefaultAzureConfig(cfg.token, cfg.baseURL)
 		defaultAzureConfig.AzureModelMapperFunc = func(model string) string {
+			// Embedding requests go to the deployment named by WithEmbeddingModel.
+			if model == cfg.embeddingModel {
+				return model
+			}
 			return cfg.model
 		}
 		if cfg.apiVersion != "" {
 			defaultAzureConfig.APIVersion = cfg.apiVersion
 		}
 		defaultAzureConfig.HTTPClient = httpClient
-		engine.client = openai.NewClientWithConfig(defaultAzureConfig)
+		engine.client = openaiBackend{openai.NewClientWithConfig(defaultAzureConfig)}
 
 	default:
 		// Default mode: OpenAI-compatible API
@@ -124,14 +224,145 @@ func New(opts ...Option) (*Client, error) {
 		if cfg.apiVersion != "" {
 			c.APIVersion = cfg.apiVersion
 		}
-		engine.client = openai.NewClientWithConfig(c)
+		engine.client = openaiBackend{openai.NewClientWithConfig(c)}
 	}
 	// Return the resulting client engine.
 	return engine, nil
 }
 
+// NewWithBackend creates a client that sends its requests to a custom Backend, e.g. an in-house
+// gateway or a test double, keeping the convenience API of Client. Options that configure the
+// HTTP transport or the provider (token, base URL, proxy, retries, key pool, logging) have no effect;
+// the backend handles them. The model defaults to DefaultModel.
+func NewWithBackend(b Backend, opts ...Option) (*Client, error) {
+	if b == nil {
+		return nil, errors.New("backend is nil")
+	}
+	cfg := newConfig(opts...)
+	if cfg.model == "" {
+		cfg.model = defaultModel
+	}
+
+	engine, err := newClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	engine.client = b
+	return engine, nil
+}
+
+// newClient creates a client from a validated config, without its backend.
+func newClient(cfg *config) (*Client, error) {
+	// Create a new client instance with the necessary fields.
+	engine := &Client{
+		name:             clientName(cfg),
+		model:            cfg.model,
+		temperature:      cfg.temperature,
+		topP:             cfg.topP,
+		presencePenalty:  cfg.presencePenalty,
+		frequencyPenalty: cfg.frequencyPenalty,
+
+		tools:             &toolRegistry{},
+		maxToolIterations: cfg.maxToolIterations,
+		jsonSchema:        supportsJSONSchema(cfg),
+		streamUsage:       supportsStreamUsage(cfg),
+		limiter:           newRateLimiter(cfg.rpm, cfg.tpm),
+
+		cache:               cfg.cache,
+		cacheMaxTemperature: cfg.cacheMaxTemperature,
+
+		embeddingModel:       openai.EmbeddingModel(cfg.embeddingModel),
+		embeddingBatchSize:   cfg.embeddingBatchSize,
+		embeddingConcurrency: cfg.embeddingConcurrency,
+
+		usage: cfg.usage,
+	}
+	if engine.embeddingBatchSize < 1 {
+		engine.embeddingBatchSize = embeddingBatchSize(cfg)
+	}
+	if cfg.jsonSchema != nil {
+		engine.jsonSchema = *cfg.jsonSchema
+	}
+	if cfg.streamUsage != nil {
+		engine.streamUsage = *cfg.streamUsage
+	}
+	if err := engine.tools.register(cfg.tools...); err != nil {
+		return nil, err
+	}
+	telemetry, err := newTelemetry(cfg)
+	if err != nil {
+		return nil, err
+	}
+	engine.telemetry = telemetry
+	return engine, nil
+}
+
+// Name returns the name the client reports in Response.Provider.
+func (c *Client) Name() string {
+	return c.name
+}
+
+// ListModels returns the models the backend offers, e.g. to check a model name before use.
+func (c *Client) ListModels(ctx context.Context) ([]openai.Model, error) {
+	ctx, header := withResponseHeader(ctx)
+	list, err := c.client.ListModels(ctx)
+	if err != nil {
+		err = c.wrapError(err, header)
+		return nil, fmt.Errorf("list models failed: %w", err)
+	}
+	return list.Models, nil
+}
+
+// clientName derives the client name from the configuration:
+// the explicit name, the host of a custom base URL, or the provider.
+func clientName(cfg *config) string {
+	if cfg.name != "" {
+		return cfg.name
+	}
+	if cfg.provider != Azure && cfg.baseURL != "" {
+		if u, err := url.Parse(cfg.baseURL); err == nil && u.Host != "" {
+			return u.Host
+		}
+	}
+	return cfg.provider
+}
+
+// envProxy returns the proxy function of a proxy read from the environment. Like
+// http.ProxyFromEnvironment, it connects directly to the hosts listed in noProxy and to
+// localhost, e.g. a local Ollama or an intranet gateway.
+func envProxy(proxyURL, noProxy string) func(*http.Request) (*url.URL, error) {
+	cfg := httpproxy.Config{HTTPProxy: proxyURL, HTTPSProxy: proxyURL, NoProxy: noProxy}
+	proxyFunc := cfg.ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return proxyFunc(req.URL)
+	}
+}
+
+// socksDialer returns the dialer of a SOCKS5 proxy given as host:port or as a
+// socks5:// URL, whose user info authenticates with the proxy.
+func socksDialer(raw string) (proxy.Dialer, error) {
+	addr := raw
+	var auth *proxy.Auth
+	if strings.Contains(raw, "://") {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, err
+		}
+		if u.Scheme != "socks5" && u.Scheme != "socks5h" {
+			return nil, fmt.Errorf("unsupported SOCKS proxy scheme %q", u.Scheme)
+		}
+		addr = u.Host
+		if u.User != nil {
+			password, _ := u.User.Password()
+			auth = &proxy.Auth{User: u.User.Username(), Password: password}
+		}
+	}
+	return proxy.SOCKS5("tcp", addr, auth, proxy.Direct)
+}
+
 // buildChatCompletionRequest creates a standardized chat completion request
-// with common configuration parameters.
+// with common configuration parameters. Tools are left out: only RunWithTools
+// executes the calls the model requests.
 func (c *Client) buildChatCompletionRequest(
 	messages []openai.ChatCompletionMessage,
 ) openai.ChatCompletionRequest {
@@ -145,16 +376,51 @@ func (c *Client) buildChatCompletionRequest(
 	}
 }
 
+// createChatCompletion serves a chat completion request from the cache, or sends it
+// once the rate limiter admits it. Every blocking chat call of the client goes through here.
+func (c *Client) createChatCompletion(
+	ctx context.Context,
+	req openai.ChatCompletionRequest,
+) (openai.ChatCompletionResponse, error) {
+	resp, key, hit := c.cachedChatCompletion(ctx, req)
+	if hit {
+		return resp, nil
+	}
+
+	ctx, op := c.telemetry.startChat(ctx, req)
+	res, err := c.reserve(ctx, req)
+	if err != nil {
+		op.endChat(ctx, resp, err)
+		return openai.ChatCompletionResponse{}, err
+	}
+	ctx, header := withResponseHeader(ctx)
+	resp, err = c.client.CreateChatCompletion(ctx, req)
+	err = c.wrapError(err, header)
+	res.settle(resp.Usage, err)
+	op.endChat(ctx, resp, err)
+	if err == nil {
+		c.track(ctx, resp.Model, resp.Usage)
+		c.storeChatCompletion(ctx, key, resp)
+	}
+	return resp, err
+}
+
 // CreateChatCompletion is an API call to create a completion for a chat message.
 func (c *Client) CreateChatCompletion(
 	ctx context.Context,
 	prompt,
 	content string,
 ) (resp openai.ChatCompletionResponse, err error) {
+	req := c.buildChatCompletionRequest(newCompletionMessages(prompt, content))
+	return c.createChatCompletion(ctx, req)
+}
+
+// newCompletionMessages builds the system and user messages for a single-turn completion.
+func newCompletionMessages(prompt, content string) []openai.ChatCompletionMessage {
 	if len(prompt) == 0 {
 		prompt = "You are a helpful assistant."
 	}
-	messages := []openai.ChatCompletionMessage{
+	return []openai.ChatCompletionMessage{
 		{
 			Role:    openai.ChatMessageRoleSystem,
 			Content: prompt,
@@ -164,9 +430,6 @@ func (c *Client) CreateChatCompletion(
 			Content: content,
 		},
 	}
-
-	req := c.buildChatCompletionRequest(messages)
-	return c.client.CreateChatCompletion(ctx, req)
 }
 
 // CreateChatCompletionWithMessage is an API call to create a completion for a chat message.
@@ -175,7 +438,7 @@ func (c *Client) CreateChatCompletionWithMessage(
 	messages []openai.ChatCompletionMessage,
 ) (resp openai.ChatCompletionResponse, err error) {
 	req := c.buildChatCompletionRequest(messages)
-	return c.client.CreateChatCompletion(ctx, req)
+	return c.createChatCompletion(ctx, req)
 }
 
 // Completion is a method on the Client struct that takes a context.Context and a string argument
@@ -192,11 +455,14 @@ func (c *Client) Completion(
 
 	// Validate response to prevent panics on empty choices
 	if len(r.Choices) == 0 {
-		return nil, errors.New("empty response from API: no choices returned")
+		return nil, ErrEmptyResponse
 	}
 
 	resp.Content = r.Choices[0].Message.Content
 	resp.Usage = r.Usage
+	resp.FinishReason = r.Choices[0].FinishReason
+	resp.Provider = c.name
+	resp.CacheHit = IsCacheHit(r)
 	return resp, nil
 }
 
@@ -230,7 +496,7 @@ func (c *Client) CreateImageChatCompletion(
 	}
 
 	req := c.buildChatCompletionRequest(messages)
-	return c.client.CreateChatCompletion(ctx, req)
+	return c.createChatCompletion(ctx, req)
 }
 
 // ImageCompletion is a method on the Client struct for image understanding.
@@ -248,11 +514,14 @@ func (c *Client) ImageCompletion(
 
 	// Validate response to prevent panics on empty choices
 	if len(r.Choices) == 0 {
-		return nil, errors.New("empty response from API: no choices returned")
+		return nil, ErrEmptyResponse
 	}
 
 	return &Response{
-		Content: r.Choices[0].Message.Content,
-		Usage:   r.Usage,
+		Content:      r.Choices[0].Message.Content,
+		Usage:        r.Usage,
+		FinishReason: r.Choices[0].FinishReason,
+		Provider:     c.name,
+		CacheHit:     IsCacheHit(r),
 	}, nil
 }
@@ -0,0 +1,486 @@
+// Package openaitest provides an in-process fake of an OpenAI-compatible API for tests
+// of code built on openai.Client.
+//
+// The Server answers chat completions (blocking and streamed), embeddings and the model
+// list, on both the OpenAI paths and the Azure deployment paths. Replies are scripted
+// with Reply or OnChat, canned failures such as RateLimited are provided, and
+// every request is captured for assertions:
+//
+//	srv := openaitest.NewServer(t)
+//	srv.Reply(openaitest.Text("Paris"), openaitest.RateLimited)
+//	client := srv.Client()
+//	resp, err := client.Completion(ctx, "", "Capital of France?")
+package openaitest
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	openaisdk "github.com/sashabaranov/go-openai"
+	"github.com/ysicing/openai/openai"
+)
+
+const (
+	// Token is the API key of the clients returned by Client and AzureClient.
+	Token = "test-token"
+	// AzureAPIVersion is the API version of the clients returned by AzureClient.
+	AzureAPIVersion = "2024-06-01"
+
+	// defaultDimensions is the length of embedding vectors unless the request sets dimensions.
+	defaultDimensions = 8
+)
+
+// Reply is a scripted answer to a chat completion request.
+// A Reply with a Status other than 0 or 200 is sent as an API error.
+type Reply struct {
+	Content   string
+	ToolCalls []openaisdk.ToolCall
+	// FinishReason defaults to stop, or tool_calls when ToolCalls are set.
+	FinishReason openaisdk.FinishReason
+	// Usage defaults to a word count of the request and the reply.
+	Usage *openaisdk.Usage
+	// NoChoices sends a successful response without choices.
+	NoChoices bool
+	// Chunks are the content deltas of a streamed reply. Defaults to Content split into words.
+	Chunks []string
+
+	Status int
+	Error  openaisdk.APIError
+	// Header is added to the response, e.g. Retry-After.
+	Header http.Header
+}
+
+// Text returns a reply with the given content.
+func Text(content string) Reply {
+	return Reply{Content: content}
+}
+
+// ToolCall returns a reply that calls the named function with JSON arguments.
+func ToolCall(name, arguments string) Reply {
+	return Reply{ToolCalls: []openaisdk.ToolCall{{
+		ID:       "call_" + name,
+		Type:     openaisdk.ToolTypeFunction,
+		Function: openaisdk.FunctionCall{Name: name, Arguments: arguments},
+	}}}
+}
+
+// Error returns a reply that fails with the given status, error code and message.
+func Error(status int, code, message string) Reply {
+	return Reply{
+		Status: status,
+		Error:  openaisdk.APIError{Code: code, Message: message, Type: http.StatusText(status)},
+	}
+}
+
+// Canned failures.
+var (
+	// RateLimited is a 429 response asking to retry after one second.
+	RateLimited = Reply{
+		Status: http.StatusTooManyRequests,
+		Error:  openaisdk.APIError{Code: "rate_limit_exceeded", Type: "requests", Message: "Rate limit reached, please try again in 1s."},
+		Header: http.Header{"Retry-After": {"1"}},
+	}
+	// InternalError is a 500 response.
+	InternalError = Error(http.StatusInternalServerError, "server_error", "The server had an error while processing your request.")
+	// ContentFiltered is the 400 response of a prompt rejected by the content filter.
+	ContentFiltered = Error(http.StatusBadRequest, "content_filter", "The response was filtered due to the prompt triggering content management policy.")
+	// EmptyChoices is a successful response without any choice.
+	EmptyChoices = Reply{NoChoices: true}
+)
+
+// Request is a captured request.
+type Request struct {
+	Method string
+	// Path is the URL path without the query.
+	Path   string
+	Query  string
+	Header http.Header
+	Body   []byte
+	// Deployment is the Azure deployment of the path, if any.
+	Deployment string
+}
+
+// Chat decodes the body of a chat completion request.
+func (r Request) Chat() (openaisdk.ChatCompletionRequest, error) {
+	var req openaisdk.ChatCompletionRequest
+	err := json.Unmarshal(r.Body, &req)
+	return req, err
+}
+
+// Server is a fake OpenAI-compatible API. It is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+	tb testing.TB
+
+	mu       sync.Mutex
+	replies  []Reply
+	onChat   func(openaisdk.ChatCompletionRequest) Reply
+	embedErr *Reply
+	models   []string
+	requests []Request
+}
+
+// NewServer starts a Server that is closed when the test ends.
+// Without scripted replies, chat completions echo the last user message.
+func NewServer(tb testing.TB) *Server {
+	tb.Helper()
+	s := &Server{
+		tb:     tb,
+		models: []string{openaisdk.GPT4oMini, string(openaisdk.SmallEmbedding3)},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	tb.Cleanup(s.Close)
+	return s
+}
+
+// Reply queues replies for the next chat completion requests, one per request.
+func (s *Server) Reply(replies ...Reply) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.replies = append(s.replies, replies...)
+}
+
+// OnChat sets the function that answers chat completion requests once the queued replies are used.
+func (s *Server) OnChat(fn func(openaisdk.ChatCompletionRequest) Reply) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onChat = fn
+}
+
+// FailEmbeddings makes embedding requests fail with the reply's error. Nil restores them.
+func (s *Server) FailEmbeddings(reply *Reply) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.embedErr = reply
+}
+
+// SetModels sets the IDs listed by the models endpoint.
+func (s *Server) SetModels(ids ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.models = ids
+}
+
+// Requests returns the captured requests in arrival order.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// ChatRequests returns the decoded chat completion requests in arrival order.
+func (s *Server) ChatRequests() []openaisdk.ChatCompletionRequest {
+	var out []openaisdk.ChatCompletionRequest
+	for _, r := range s.Requests() {
+		if !strings.HasSuffix(r.Path, "/chat/completions") {
+			continue
+		}
+		if req, err := r.Chat(); err == nil {
+			out = append(out, req)
+		}
+	}
+	return out
+}
+
+// Client returns a client of the server. The options are applied after the base URL and token.
+func (s *Server) Client(opts ...openai.Option) *openai.Client {
+	s.tb.Helper()
+	return s.newClient(append([]openai.Option{
+		openai.WithToken(Token),
+		openai.WithBaseURL(s.URL + "/v1"),
+	}, opts...))
+}
+
+// AzureClient returns a client of the server that uses the Azure deployment paths.
+func (s *Server) AzureClient(opts ...openai.Option) *openai.Client {
+	s.tb.Helper()
+	return s.newClient(append([]openai.Option{
+		openai.WithToken(Token),
+		openai.WithProvider(openai.Azure),
+		openai.WithBaseURL(s.URL),
+		openai.WithApiVersion(AzureAPIVersion),
+	}, opts...))
+}
+
+func (s *Server) newClient(opts []openai.Option) *openai.Client {
+	s.tb.Helper()
+	client, err := openai.New(opts...)
+	if err != nil {
+		s.tb.Fatalf("openaitest: failed to create client: %v", err)
+	}
+	return client
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	req := Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.RawQuery,
+		Header: r.Header.Clone(),
+		Body:   body,
+	}
+	if rest, ok := strings.CutPrefix(r.URL.Path, "/openai/deployments/"); ok {
+		req.Deployment, _, _ = strings.Cut(rest, "/")
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	s.mu.Unlock()
+
+	switch {
+	case strings.HasSuffix(req.Path, "/chat/completions") && r.Method == http.MethodPost:
+		s.serveChat(w, body)
+	case strings.HasSuffix(req.Path, "/embeddings") && r.Method == http.MethodPost:
+		s.serveEmbeddings(w, body)
+	case strings.HasSuffix(req.Path, "/models") && r.Method == http.MethodGet:
+		s.serveModels(w)
+	default:
+		writeError(w, Error(http.StatusNotFound, "unknown_url", "Unknown request URL: "+r.Method+" "+req.Path))
+	}
+}
+
+// nextReply pops the next queued reply, falling back to OnChat and then to an echo.
+func (s *Server) nextReply(req openaisdk.ChatCompletionRequest) Reply {
+	s.mu.Lock()
+	if len(s.replies) > 0 {
+		reply := s.replies[0]
+		s.replies = s.replies[1:]
+		s.mu.Unlock()
+		return reply
+	}
+	onChat := s.onChat
+	s.mu.Unlock()
+
+	if onChat != nil {
+		return onChat(req)
+	}
+	for i := len(req.Messages) - 1; i >= 0; i-- {
+		if req.Messages[i].Role == openaisdk.ChatMessageRoleUser {
+			return Text(req.Messages[i].Content)
+		}
+	}
+	return Text("")
+}
+
+func (s *Server) serveChat(w http.ResponseWriter, body []byte) {
+	var req openaisdk.ChatCompletionRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, Error(http.StatusBadRequest, "invalid_request_error", err.Error()))
+		return
+	}
+
+	reply := s.nextReply(req)
+	for key, values := range reply.Header {
+		w.Header()[key] = values
+	}
+	if reply.Status != 0 && reply.Status != http.StatusOK {
+		writeError(w, reply)
+		return
+	}
+
+	resp := completion(req, reply)
+	if req.Stream {
+		writeStream(w, req, reply, resp)
+		return
+	}
+	writeJSON(w, resp)
+}
+
+// completion builds the response of a successful reply.
+func completion(req openaisdk.ChatCompletionRequest, reply Reply) openaisdk.ChatCompletionResponse {
+	resp := openaisdk.ChatCompletionResponse{
+		ID:      "chatcmpl-openaitest",
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   req.Model,
+	}
+
+	if reply.Usage != nil {
+		resp.Usage = *reply.Usage
+	} else {
+		prompt := 0
+		for _, m := range req.Messages {
+			prompt += len(strings.Fields(m.Content))
+		}
+		resp.Usage = openaisdk.Usage{PromptTokens: prompt, CompletionTokens: len(strings.Fields(reply.Content))}
+		resp.Usage.TotalTokens = resp.Usage.PromptTokens + resp.Usage.CompletionTokens
+	}
+
+	if reply.NoChoices {
+		resp.Choices = []openaisdk.ChatCompletionChoice{}
+		return resp
+	}
+	finish := reply.FinishReason
+	if finish == "" {
+		finish = openaisdk.FinishReasonStop
+		if len(reply.ToolCalls) > 0 {
+			finish = openaisdk.FinishReasonToolCalls
+		}
+	}
+	resp.Choices = []openaisdk.ChatCompletionChoice{{
+		Message: openaisdk.ChatCompletionMessage{
+			Role:      openaisdk.ChatMessageRoleAssistant,
+			Content:   reply.Content,
+			ToolCalls: reply.ToolCalls,
+		},
+		FinishReason: finish,
+	}}
+	return resp
+}
+
+// writeStream sends the reply as server-sent events the way the API streams a completion.
+func writeStream(w http.ResponseWriter, req openaisdk.ChatCompletionRequest, reply Reply, resp openaisdk.ChatCompletionResponse) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	flusher, _ := w.(http.Flusher)
+
+	send := func(choices []openaisdk.ChatCompletionStreamChoice, usage *openaisdk.Usage) {
+		data, _ := json.Marshal(openaisdk.ChatCompletionStreamResponse{
+			ID:      resp.ID,
+			Object:  "chat.completion.chunk",
+			Created: resp.Created,
+			Model:   resp.Model,
+			Choices: choices,
+			Usage:   usage,
+		})
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	if len(resp.Choices) > 0 {
+		send([]openaisdk.ChatCompletionStreamChoice{{
+			Delta: openaisdk.ChatCompletionStreamChoiceDelta{Role: openaisdk.ChatMessageRoleAssistant},
+		}}, nil)
+
+		chunks := reply.Chunks
+		if chunks == nil && reply.Content != "" {
+			chunks = strings.SplitAfter(reply.Content, " ")
+		}
+		for _, chunk := range chunks {
+			send([]openaisdk.ChatCompletionStreamChoice{{
+				Delta: openaisdk.ChatCompletionStreamChoiceDelta{Content: chunk},
+			}}, nil)
+		}
+		for i, call := range reply.ToolCalls {
+			call.Index = &i
+			send([]openaisdk.ChatCompletionStreamChoice{{
+				Delta: openaisdk.ChatCompletionStreamChoiceDelta{ToolCalls: []openaisdk.ToolCall{call}},
+			}}, nil)
+		}
+		send([]openaisdk.ChatCompletionStreamChoice{{FinishReason: resp.Choices[0].FinishReason}}, nil)
+	}
+
+	if req.StreamOptions != nil && req.StreamOptions.IncludeUsage {
+		send([]openaisdk.ChatCompletionStreamChoice{}, &resp.Usage)
+	}
+	fmt.Fprint(w, "data: [DONE]\n\n")
+}
+
+func (s *Server) serveEmbeddings(w http.ResponseWriter, body []byte) {
+	s.mu.Lock()
+	embedErr := s.embedErr
+	s.mu.Unlock()
+	if embedErr != nil {
+		for key, values := range embedErr.Header {
+			w.Header()[key] = values
+		}
+		writeError(w, *embedErr)
+		return
+	}
+
+	var req struct {
+		Input      any    `json:"input"`
+		Model      string `json:"model"`
+		Dimensions int    `json:"dimensions"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, Error(http.StatusBadRequest, "invalid_request_error", err.Error()))
+		return
+	}
+
+	var inputs []string
+	switch v := req.Input.(type) {
+	case string:
+		inputs = []string{v}
+	case []any:
+		for _, item := range v {
+			text, _ := item.(string)
+			inputs = append(inputs, text)
+		}
+	}
+
+	dims := req.Dimensions
+	if dims <= 0 {
+		dims = defaultDimensions
+	}
+	resp := openaisdk.EmbeddingResponse{
+		Object: "list",
+		Model:  openaisdk.EmbeddingModel(req.Model),
+	}
+	for i, text := range inputs {
+		resp.Data = append(resp.Data, openaisdk.Embedding{
+			Object:    "embedding",
+			Index:     i,
+			Embedding: Vector(text, dims),
+		})
+		resp.Usage.PromptTokens += len(strings.Fields(text))
+	}
+	resp.Usage.TotalTokens = resp.Usage.PromptTokens
+	writeJSON(w, resp)
+}
+
+// Vector returns the deterministic unit vector the server embeds text as.
+func Vector(text string, dims int) []float32 {
+	vec := make([]float32, dims)
+	var norm float64
+	for i := range vec {
+		sum := sha256.Sum256(fmt.Appendf(nil, "%d:%s", i, text))
+		v := float64(binary.BigEndian.Uint32(sum[:4]))/math.MaxUint32*2 - 1
+		vec[i] = float32(v)
+		norm += v * v
+	}
+	norm = math.Sqrt(norm)
+	for i := range vec {
+		vec[i] = float32(float64(vec[i]) / norm)
+	}
+	return vec
+}
+
+func (s *Server) serveModels(w http.ResponseWriter) {
+	s.mu.Lock()
+	ids := append([]string(nil), s.models...)
+	s.mu.Unlock()
+
+	list := openaisdk.ModelsList{}
+	for _, id := range ids {
+		list.Models = append(list.Models, openaisdk.Model{ID: id, Object: "model", OwnedBy: "openaitest"})
+	}
+	writeJSON(w, list)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, reply Reply) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(reply.Status)
+	_ = json.NewEncoder(w).Encode(map[string]any{"error": map[string]any{
+		"message": reply.Error.Message,
+		"type":    reply.Error.Type,
+		"code":    reply.Error.Code,
+	}})
+}
@@ -0,0 +1,200 @@
+package openaitest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	openaisdk "github.com/sashabaranov/go-openai"
+	"github.com/ysicing/openai/openai"
+)
+
+func TestServer_Completion(t *testing.T) {
+	srv := NewServer(t)
+	srv.Reply(Text("Paris"))
+	client := srv.Client(openai.WithModel("gpt-4o"))
+
+	resp, err := client.Completion(context.Background(), "Be brief.", "Capital of France?")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if resp.Content != "Paris" || resp.FinishReason != openaisdk.FinishReasonStop {
+		t.Errorf("Expected 'Paris' and stop, got '%s' and %s", resp.Content, resp.FinishReason)
+	}
+
+	// Unscripted requests echo the last user message.
+	resp, err = client.Completion(context.Background(), "", "echo me")
+	if err != nil || resp.Content != "echo me" {
+		t.Errorf("Expected echo, got %+v (%v)", resp, err)
+	}
+
+	requests := srv.ChatRequests()
+	if len(requests) != 2 || requests[0].Model != "gpt-4o" || requests[0].Messages[0].Content != "Be brief." {
+		t.Errorf("Expected captured requests, got %+v", requests)
+	}
+	if auth := srv.Requests()[0].Header.Get("Authorization"); auth != "Bearer "+Token {
+		t.Errorf("Expected bearer token, got '%s'", auth)
+	}
+}
+
+func TestServer_CannedErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		reply  Reply
+		status int
+	}{
+		{"Rate limited", RateLimited, http.StatusTooManyRequests},
+		{"Internal error", InternalError, http.StatusInternalServerError},
+		{"Content filtered", ContentFiltered, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := NewServer(t)
+			srv.Reply(tt.reply)
+
+			_, err := srv.Client().Completion(context.Background(), "", "hi")
+			var apiErr *openaisdk.APIError
+			if !errors.As(err, &apiErr) || apiErr.HTTPStatusCode != tt.status || apiErr.Code != tt.reply.Error.Code {
+				t.Errorf("Expected API error %d %v, got: %v", tt.status, tt.reply.Error.Code, err)
+			}
+		})
+	}
+
+	srv := NewServer(t)
+	srv.Reply(EmptyChoices)
+	if _, err := srv.Client().Completion(context.Background(), "", "hi"); err == nil || !strings.Contains(err.Error(), "no choices") {
+		t.Errorf("Expected empty response error, got: %v", err)
+	}
+}
+
+func TestServer_RetryAfterRateLimit(t *testing.T) {
+	srv := NewServer(t)
+	srv.Reply(Reply{Status: http.StatusTooManyRequests, Header: http.Header{"Retry-After-Ms": {"1"}}}, Text("ok"))
+
+	resp, err := srv.Client(openai.WithRetry(1)).Completion(context.Background(), "", "hi")
+	if err != nil || resp.Content != "ok" {
+		t.Errorf("Expected 'ok' after a retry, got %+v (%v)", resp, err)
+	}
+	if n := len(srv.Requests()); n != 2 {
+		t.Errorf("Expected 2 requests, got %d", n)
+	}
+}
+
+func TestServer_Stream(t *testing.T) {
+	srv := NewServer(t)
+	srv.Reply(Reply{Content: "Hello, world", Chunks: []string{"Hello", ", world"}})
+
+	stream, err := srv.Client().CompletionStream(context.Background(), "", "hi")
+	if err != nil {
+		t.Fatalf("CompletionStream failed: %v", err)
+	}
+	defer stream.Close()
+
+	var parts []string
+	for delta, err := range stream.Deltas() {
+		if err != nil {
+			t.Fatalf("Unexpected stream error: %v", err)
+		}
+		if delta.Content != "" {
+			parts = append(parts, delta.Content)
+		}
+	}
+	if strings.Join(parts, "|") != "Hello|, world" {
+		t.Errorf("Expected deltas 'Hello|, world', got '%s'", strings.Join(parts, "|"))
+	}
+
+	resp, err := stream.Response()
+	if err != nil {
+		t.Fatalf("Response failed: %v", err)
+	}
+	if resp.Usage.CompletionTokens != 2 || resp.FinishReason != openaisdk.FinishReasonStop {
+		t.Errorf("Expected usage and finish reason, got %+v", resp)
+	}
+}
+
+func TestServer_ToolCall(t *testing.T) {
+	srv := NewServer(t)
+	srv.Reply(ToolCall("get_weather", `{"city":"Paris"}`), Text("Sunny"))
+
+	weather := openai.Tool{
+		Name:        "get_weather",
+		Description: "Get the weather",
+		Parameters:  json.RawMessage(`{"type":"object","properties":{"city":{"type":"string"}}}`),
+		Handler: func(_ context.Context, args string) (string, error) {
+			return "sunny in " + args, nil
+		},
+	}
+	client := srv.Client(openai.WithTools(weather))
+
+	resp, _, err := client.RunWithTools(context.Background(), []openaisdk.ChatCompletionMessage{
+		{Role: openaisdk.ChatMessageRoleUser, Content: "Weather in Paris?"},
+	})
+	if err != nil || resp.Content != "Sunny" {
+		t.Fatalf("Expected 'Sunny', got %+v (%v)", resp, err)
+	}
+
+	requests := srv.ChatRequests()
+	last := requests[len(requests)-1].Messages
+	if len(requests) != 2 || last[len(last)-1].Role != openaisdk.ChatMessageRoleTool {
+		t.Errorf("Expected the tool result to be sent back, got %+v", last)
+	}
+}
+
+func TestServer_Embeddings(t *testing.T) {
+	srv := NewServer(t)
+	client := srv.Client()
+
+	resp, err := client.Embed(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(resp.Vectors) != 2 || len(resp.Vectors[0]) != defaultDimensions {
+		t.Fatalf("Expected 2 vectors of %d dimensions, got %v", defaultDimensions, resp.Vectors)
+	}
+	if resp.Vectors[1][0] != Vector("b", defaultDimensions)[0] {
+		t.Error("Expected deterministic vectors")
+	}
+
+	failure := InternalError
+	srv.FailEmbeddings(&failure)
+	if _, err := client.EmbedOne(context.Background(), "a"); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
+
+func TestServer_Azure(t *testing.T) {
+	srv := NewServer(t)
+	client := srv.AzureClient(openai.WithModel("my-deployment"))
+
+	if _, err := client.Completion(context.Background(), "", "hi"); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	req := srv.Requests()[0]
+	if req.Deployment != "my-deployment" || req.Path != "/openai/deployments/my-deployment/chat/completions" {
+		t.Errorf("Expected the Azure deployment path, got %s", req.Path)
+	}
+	if req.Header.Get("Api-Key") != Token || !strings.Contains(req.Query, AzureAPIVersion) {
+		t.Errorf("Expected api-key header and api-version, got %v %s", req.Header, req.Query)
+	}
+}
+
+func TestServer_Models(t *testing.T) {
+	srv := NewServer(t)
+	srv.SetModels("a", "b")
+
+	resp, err := http.Get(srv.URL + "/v1/models")
+	if err != nil {
+		t.Fatalf("Failed to list models: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var list openaisdk.ModelsList
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil || len(list.Models) != 2 || list.Models[1].ID != "b" {
+		t.Errorf("Expected models a and b, got %+v (%v)", list.Models, err)
+	}
+}
@@ -1,6 +1,7 @@
 package openai
 
 import (
+	"context"
 	"testing"
 	"time"
 )
@@ -58,6 +59,15 @@ func TestWithTemperature(t *testing.T) {
 	}
 }
 
+func TestWithZeroTemperature(t *testing.T) {
+	c := &config{}
+	WithZeroTemperature().apply(c)
+
+	if c.temperature != zeroTemperature {
+		t.Errorf("Expected zero temperature %g, got %g", zeroTemperature, c.temperature)
+	}
+}
+
 func TestWithTimeout(t *testing.T) {
 	duration := 30 * time.Second
 	opt := WithTimeout(duration)
@@ -111,7 +121,7 @@ func TestWithProvider(t *testing.T) {
 	}{
 		{"OpenAI", OpenAI, OpenAI},
 		{"Azure", Azure, Azure},
-		{"Ollama (uses default)", "ollama", defaultProvider},     // Uses default mode
+		{"Ollama", Ollama, Ollama},
 		{"DeepSeek (uses default)", "deepseek", defaultProvider}, // Uses default mode
 		{"ZhiPu (uses default)", "zhipu", defaultProvider},       // Uses default mode
 		{"Unknown provider", "unknown", defaultProvider},
@@ -305,3 +315,145 @@ func TestOptionInterface(t *testing.T) {
 		t.Errorf("Expected token 'test-token', got '%s'", c.token)
 	}
 }
+
+func TestWithTools(t *testing.T) {
+	handler := func(context.Context, string) (string, error) { return "", nil }
+	opt := WithTools(Tool{Name: "a", Handler: handler}, Tool{Name: "b", Handler: handler})
+
+	c := &config{}
+	opt.apply(c)
+
+	if len(c.tools) != 2 {
+		t.Errorf("Expected 2 tools, got %d", len(c.tools))
+	}
+}
+
+func TestWithMaxToolIterations(t *testing.T) {
+	c := &config{maxToolIterations: defaultMaxToolIterations}
+	WithMaxToolIterations(0).apply(c)
+
+	if c.maxToolIterations != defaultMaxToolIterations {
+		t.Errorf("Expected default max tool iterations %d, got %d", defaultMaxToolIterations, c.maxToolIterations)
+	}
+
+	WithMaxToolIterations(3).apply(c)
+
+	if c.maxToolIterations != 3 {
+		t.Errorf("Expected max tool iterations 3, got %d", c.maxToolIterations)
+	}
+}
+
+func TestWithJSONSchema(t *testing.T) {
+	c := &config{}
+	WithJSONSchema(false).apply(c)
+
+	if c.jsonSchema == nil || *c.jsonSchema {
+		t.Error("Expected jsonSchema to be explicitly disabled")
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	c := &config{}
+	WithRetry(3).apply(c)
+	WithRetryBackoff(time.Second, time.Minute).apply(c)
+
+	if c.maxRetries != 3 {
+		t.Errorf("Expected maxRetries 3, got %d", c.maxRetries)
+	}
+
+	if c.retryBaseDelay != time.Second || c.retryMaxDelay != time.Minute {
+		t.Errorf("Expected backoff (1s, 1m), got (%v, %v)", c.retryBaseDelay, c.retryMaxDelay)
+	}
+}
+
+func TestWithRateLimit(t *testing.T) {
+	c := &config{}
+	WithRateLimit(60, 90000).apply(c)
+
+	if c.rpm != 60 || c.tpm != 90000 {
+		t.Errorf("Expected rate limit (60, 90000), got (%d, %d)", c.rpm, c.tpm)
+	}
+}
+
+func TestWithName(t *testing.T) {
+	c := &config{}
+	WithName("primary").apply(c)
+
+	if c.name != "primary" {
+		t.Errorf("Expected name 'primary', got '%s'", c.name)
+	}
+}
+
+func TestWithTokens(t *testing.T) {
+	c := &config{}
+	WithTokens([]string{"key-a", "", "key-b"}).apply(c)
+	WithKeySelection(LeastUsed).apply(c)
+	WithKeyCooldown(time.Minute).apply(c)
+
+	if c.keySelection != LeastUsed || c.keyCooldown != time.Minute {
+		t.Errorf("Expected (LeastUsed, 1m), got (%d, %v)", c.keySelection, c.keyCooldown)
+	}
+
+	// The first key becomes the default token and blank keys are dropped.
+	if err := c.valid(); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if c.token != "key-a" {
+		t.Errorf("Expected token 'key-a', got '%s'", c.token)
+	}
+
+	if len(c.tokens) != 2 {
+		t.Errorf("Expected 2 keys, got %v", c.tokens)
+	}
+}
+
+func TestWithCache(t *testing.T) {
+	c := newConfig(WithCache(NewMemoryCache(10, 0)))
+
+	if c.cache == nil {
+		t.Error("Expected cache to be set")
+	}
+
+	if c.cacheMaxTemperature != defaultCacheMaxTemperature {
+		t.Errorf("Expected default max temperature %f, got %f", defaultCacheMaxTemperature, c.cacheMaxTemperature)
+	}
+
+	WithCacheMaxTemperature(0.7).apply(c)
+	if c.cacheMaxTemperature != 0.7 {
+		t.Errorf("Expected max temperature 0.7, got %f", c.cacheMaxTemperature)
+	}
+}
+
+func TestWithEmbeddingModel(t *testing.T) {
+	c := newConfig()
+	if c.embeddingModel != string(defaultEmbeddingModel) || c.embeddingConcurrency != defaultEmbeddingConcurrency {
+		t.Errorf("Expected embedding defaults, got (%s, %d)", c.embeddingModel, c.embeddingConcurrency)
+	}
+
+	WithEmbeddingModel("text-embedding-3-large").apply(c)
+	WithEmbeddingBatchSize(100).apply(c)
+	WithEmbeddingConcurrency(0).apply(c)
+
+	if c.embeddingModel != "text-embedding-3-large" {
+		t.Errorf("Expected embedding model 'text-embedding-3-large', got '%s'", c.embeddingModel)
+	}
+
+	if c.embeddingBatchSize != 100 {
+		t.Errorf("Expected batch size 100, got %d", c.embeddingBatchSize)
+	}
+
+	if c.embeddingConcurrency != defaultEmbeddingConcurrency {
+		t.Errorf("Expected invalid concurrency to be ignored, got %d", c.embeddingConcurrency)
+	}
+}
+
+func TestWithUsageTracker(t *testing.T) {
+	tracker := NewUsageTracker(nil)
+	c := &config{}
+	WithUsageTracker(tracker).apply(c)
+
+	if c.usage != tracker {
+		t.Error("Expected usage tracker to be set")
+	}
+}
@@ -0,0 +1,350 @@
+package openai
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigEnv is the environment variable that names the config file read by NewFromProfile.
+const ConfigEnv = "OPENAI_CONFIG"
+
+// configFiles are the names DefaultConfigPath looks for in the openai directory of the user config directory.
+var configFiles = []string{"config.yaml", "config.yml", "config.toml", "config.json"}
+
+// envRef matches ${NAME} and ${NAME:-default} references in profile values.
+var envRef = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// Config is the content of a config file: named client profiles and model prices.
+//
+//	default: work
+//	profiles:
+//	  work:
+//	    provider: azure
+//	    base_url: https://example.openai.azure.com
+//	    token: ${AZURE_OPENAI_API_KEY}
+//	    model: gpt-4o
+//	    timeout: 30s
+//	prices:
+//	  gpt-4o: {input: 2.5, cached_input: 1.25, output: 10}
+type Config struct {
+	// Default is the profile used when none is named.
+	Default  string             `json:"default" yaml:"default" toml:"default"`
+	Profiles map[string]Profile `json:"profiles" yaml:"profiles" toml:"profiles"`
+	// Prices override the default model prices, see Pricing.
+	Prices map[string]Price `json:"prices" yaml:"prices" toml:"prices"`
+}
+
+// Profile is a named client configuration. String values may reference environment
+// variables as ${NAME}, or ${NAME:-default} for optional ones, which keeps secrets out of the file.
+type Profile struct {
+	Provider       string   `json:"provider" yaml:"provider" toml:"provider"`
+	BaseURL        string   `json:"base_url" yaml:"base_url" toml:"base_url"`
+	Token          string   `json:"token" yaml:"token" toml:"token"`
+	Tokens         []string `json:"tokens" yaml:"tokens" toml:"tokens"`
+	OrgID          string   `json:"org_id" yaml:"org_id" toml:"org_id"`
+	Model          string   `json:"model" yaml:"model" toml:"model"`
+	EmbeddingModel string   `json:"embedding_model" yaml:"embedding_model" toml:"embedding_model"`
+	APIVersion     string   `json:"api_version" yaml:"api_version" toml:"api_version"`
+	// Name is reported in Response.Provider, see WithName.
+	Name string `json:"name" yaml:"name" toml:"name"`
+
+	Temperature      *float32 `json:"temperature" yaml:"temperature" toml:"temperature"`
+	TopP             *float32 `json:"top_p" yaml:"top_p" toml:"top_p"`
+	PresencePenalty  *float32 `json:"presence_penalty" yaml:"presence_penalty" toml:"presence_penalty"`
+	FrequencyPenalty *float32 `json:"frequency_penalty" yaml:"frequency_penalty" toml:"frequency_penalty"`
+
+	// Timeout is a duration such as "30s" or "2m".
+	Timeout    string            `json:"timeout" yaml:"timeout" toml:"timeout"`
+	Retries    int               `json:"retries" yaml:"retries" toml:"retries"`
+	ProxyURL   string            `json:"proxy_url" yaml:"proxy_url" toml:"proxy_url"`
+	SocksURL   string            `json:"socks_url" yaml:"socks_url" toml:"socks_url"`
+	SkipVerify bool              `json:"skip_verify" yaml:"skip_verify" toml:"skip_verify"`
+	Headers    map[string]string `json:"headers" yaml:"headers" toml:"headers"`
+}
+
+// LoadConfig reads a YAML (.yaml, .yml), TOML (.toml) or JSON (.json) config file. It resolves
+// the environment variable references and validates every profile, so that a mistake in any
+// profile is reported when the file is loaded rather than when the profile is first used.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	case ".toml":
+		md, err := toml.Decode(string(data), cfg)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return nil, fmt.Errorf("%s: unknown field %s", path, undecoded[0])
+		}
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(cfg); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("%s: unsupported config format %q", path, ext)
+	}
+
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(cfg.Profiles)) {
+		p := cfg.Profiles[name]
+		err := p.expand(os.LookupEnv)
+		if err == nil {
+			err = p.Validate()
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("profile %q: %w", name, err))
+		}
+		cfg.Profiles[name] = p
+	}
+	if cfg.Default != "" {
+		if _, ok := cfg.Profiles[cfg.Default]; !ok {
+			errs = append(errs, fmt.Errorf("default profile %q not found", cfg.Default))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Profile returns the named profile. An empty name selects the default profile,
+// or the only profile of a file without a default.
+func (c *Config) Profile(name string) (Profile, error) {
+	if name == "" {
+		name = c.Default
+	}
+	if name == "" && len(c.Profiles) == 1 {
+		for _, p := range c.Profiles {
+			return p, nil
+		}
+	}
+	if name == "" {
+		return Profile{}, errors.New("no profile named and no default profile set")
+	}
+	p, ok := c.Profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("profile %q not found", name)
+	}
+	return p, nil
+}
+
+// Pricing returns the default prices with the prices of the config file applied.
+func (c *Config) Pricing() *Pricing {
+	return NewPricing(c.Prices)
+}
+
+// NewClient creates a client from the named profile, see Profile. The options are applied
+// after those of the profile and take precedence.
+func (c *Config) NewClient(name string, opts ...Option) (*Client, error) {
+	p, err := c.Profile(name)
+	if err != nil {
+		return nil, err
+	}
+	return New(append(p.Options(), opts...)...)
+}
+
+// NewFromProfile creates a client from a profile of the config file named by OPENAI_CONFIG,
+// or found by DefaultConfigPath. An empty name selects the default profile.
+func NewFromProfile(name string, opts ...Option) (*Client, error) {
+	path, err := DefaultConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.NewClient(name, opts...)
+}
+
+// DefaultConfigPath returns the config file named by OPENAI_CONFIG or, failing that,
+// the first config.yaml, config.yml, config.toml or config.json in the openai
+// directory of the user config directory, e.g. ~/.config/openai on Linux.
+func DefaultConfigPath() (string, error) {
+	if path := os.Getenv(ConfigEnv); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	for _, name := range configFiles {
+		path := filepath.Join(dir, "openai", name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no config file found in %s; set %s", filepath.Join(dir, "openai"), ConfigEnv)
+}
+
+// Options returns the options that configure a client as the profile describes.
+func (p Profile) Options() []Option {
+	var opts []Option
+	add := func(val string, option func(string) Option) {
+		if val != "" {
+			opts = append(opts, option(val))
+		}
+	}
+	add(p.Provider, WithProvider)
+	add(p.BaseURL, WithBaseURL)
+	add(p.Token, WithToken)
+	add(p.OrgID, WithOrgID)
+	add(p.Model, WithModel)
+	add(p.EmbeddingModel, WithEmbeddingModel)
+	add(p.APIVersion, WithApiVersion)
+	add(p.Name, WithName)
+	add(p.ProxyURL, WithProxyURL)
+	add(p.SocksURL, WithSocksURL)
+
+	if len(p.Tokens) > 0 {
+		opts = append(opts, WithTokens(p.Tokens))
+	}
+	if p.Temperature != nil {
+		if *p.Temperature == 0 {
+			opts = append(opts, WithZeroTemperature())
+		} else {
+			opts = append(opts, WithTemperature(*p.Temperature))
+		}
+	}
+	if p.TopP != nil {
+		opts = append(opts, WithTopP(*p.TopP))
+	}
+	if p.PresencePenalty != nil {
+		opts = append(opts, WithPresencePenalty(*p.PresencePenalty))
+	}
+	if p.FrequencyPenalty != nil {
+		opts = append(opts, WithFrequencyPenalty(*p.FrequencyPenalty))
+	}
+	if d, err := time.ParseDuration(p.Timeout); err == nil {
+		opts = append(opts, WithTimeout(d))
+	}
+	if p.Retries > 0 {
+		opts = append(opts, WithRetry(p.Retries))
+	}
+	if p.SkipVerify {
+		opts = append(opts, WithSkipVerify(true))
+	}
+	if len(p.Headers) > 0 {
+		var headers []string
+		for _, k := range slices.Sorted(maps.Keys(p.Headers)) {
+			headers = append(headers, k+"="+p.Headers[k])
+		}
+		opts = append(opts, WithHeaders(headers))
+	}
+	return opts
+}
+
+// Validate checks the values of the profile and that New accepts it.
+func (p Profile) Validate() error {
+	switch p.Provider {
+	case "", OpenAI, Azure, Ollama, Anthropic, Gemini:
+	default:
+		return fmt.Errorf("unknown provider %q; use openai with base_url for OpenAI-compatible services", p.Provider)
+	}
+	if p.Provider == Azure && p.BaseURL == "" {
+		return errors.New("azure requires base_url")
+	}
+	for field, val := range map[string]string{"base_url": p.BaseURL, "proxy_url": p.ProxyURL} {
+		if val == "" {
+			continue
+		}
+		if u, err := url.Parse(val); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%s %q is not an http(s) URL", field, val)
+		}
+	}
+	if p.ProxyURL != "" && p.SocksURL != "" {
+		return errors.New("proxy_url and socks_url are mutually exclusive")
+	}
+
+	ranges := []struct {
+		field    string
+		val      *float32
+		min, max float32
+	}{
+		{"temperature", p.Temperature, 0, 2},
+		{"top_p", p.TopP, 0, 1},
+		{"presence_penalty", p.PresencePenalty, -2, 2},
+		{"frequency_penalty", p.FrequencyPenalty, -2, 2},
+	}
+	for _, r := range ranges {
+		if r.val != nil && (*r.val < r.min || *r.val > r.max) {
+			return fmt.Errorf("%s %v is out of range [%v, %v]", r.field, *r.val, r.min, r.max)
+		}
+	}
+	if p.Timeout != "" {
+		if d, err := time.ParseDuration(p.Timeout); err != nil || d < 0 {
+			return fmt.Errorf("invalid timeout %q", p.Timeout)
+		}
+	}
+	if p.Retries < 0 {
+		return fmt.Errorf("retries %d is negative", p.Retries)
+	}
+
+	return newConfig(p.Options()...).valid()
+}
+
+// expand resolves the environment variable references of the string values.
+func (p *Profile) expand(lookup func(string) (string, bool)) error {
+	var missing []string
+	expand := func(s string) string {
+		return envRef.ReplaceAllStringFunc(s, func(ref string) string {
+			m := envRef.FindStringSubmatch(ref)
+			if val, ok := lookup(m[1]); ok {
+				return val
+			}
+			if strings.Contains(ref, ":-") {
+				return m[2]
+			}
+			missing = append(missing, m[1])
+			return ""
+		})
+	}
+
+	for _, s := range []*string{
+		&p.Provider, &p.BaseURL, &p.Token, &p.OrgID, &p.Model, &p.EmbeddingModel,
+		&p.APIVersion, &p.Name, &p.Timeout, &p.ProxyURL, &p.SocksURL,
+	} {
+		*s = expand(*s)
+	}
+	p.Tokens = slices.Clone(p.Tokens)
+	for i := range p.Tokens {
+		p.Tokens[i] = expand(p.Tokens[i])
+	}
+	p.Headers = maps.Clone(p.Headers)
+	for k, v := range p.Headers {
+		p.Headers[k] = expand(v)
+	}
+
+	if len(missing) > 0 {
+		slices.Sort(missing)
+		return fmt.Errorf("environment variable %s is not set", strings.Join(slices.Compact(missing), ", "))
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+package openai
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Setenv("PROFILE_TEST_KEY", "sk-secret")
+
+	files := map[string]string{
+		"config.yaml": `
+default: work
+profiles:
+  work:
+    provider: azure
+    base_url: https://example.openai.azure.com
+    token: ${PROFILE_TEST_KEY}
+    model: gpt-4o
+    api_version: 2024-10-21
+    temperature: 0.2
+    timeout: 30s
+    headers:
+      X-Team: ${PROFILE_TEST_TEAM:-search}
+  local:
+    provider: ollama
+    model: llama3.2
+prices:
+  gpt-4o: {input: 2.5, cached_input: 1.25, output: 10}
+`,
+		"config.toml": `
+default = "work"
+
+[profiles.work]
+provider = "azure"
+base_url = "https://example.openai.azure.com"
+token = "${PROFILE_TEST_KEY}"
+model = "gpt-4o"
+api_version = "2024-10-21"
+temperature = 0.2
+timeout = "30s"
+headers = { X-Team = "${PROFILE_TEST_TEAM:-search}" }
+
+[profiles.local]
+provider = "ollama"
+model = "llama3.2"
+
+[prices.gpt-4o]
+input = 2.5
+cached_input = 1.25
+output = 10
+`,
+		"config.json": `{
+  "default": "work",
+  "profiles": {
+    "work": {
+      "provider": "azure",
+      "base_url": "https://example.openai.azure.com",
+      "token": "${PROFILE_TEST_KEY}",
+      "model": "gpt-4o",
+      "api_version": "2024-10-21",
+      "temperature": 0.2,
+      "timeout": "30s",
+      "headers": {"X-Team": "${PROFILE_TEST_TEAM:-search}"}
+    },
+    "local": {"provider": "ollama", "model": "llama3.2"}
+  },
+  "prices": {"gpt-4o": {"input": 2.5, "cached_input": 1.25, "output": 10}}
+}`,
+	}
+
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := LoadConfig(writeConfig(t, name, content))
+			if err != nil {
+				t.Fatalf("Failed to load config: %v", err)
+			}
+
+			p, err := cfg.Profile("")
+			if err != nil {
+				t.Fatalf("Expected the default profile, got: %v", err)
+			}
+			if p.Token != "sk-secret" || p.Headers["X-Team"] != "search" || p.APIVersion != "2024-10-21" {
+				t.Errorf("Expected resolved values, got %+v", p)
+			}
+			if p.Temperature == nil || *p.Temperature != 0.2 {
+				t.Errorf("Expected temperature 0.2, got %v", p.Temperature)
+			}
+
+			c := newConfig(p.Options()...)
+			if c.provider != Azure || c.model != "gpt-4o" || c.timeout.String() != "30s" || c.headers[0] != "X-Team=search" {
+				t.Errorf("Expected the profile options to apply, got %+v", c)
+			}
+			if price, ok := cfg.Pricing().Lookup("gpt-4o"); !ok || price.CachedInput != 1.25 {
+				t.Errorf("Expected the configured price, got %+v", price)
+			}
+
+			if _, err := cfg.NewClient("local"); err != nil {
+				t.Errorf("Expected the local profile to need no token, got: %v", err)
+			}
+			if _, err := cfg.Profile("missing"); err == nil {
+				t.Error("Expected error for an unknown profile, got nil")
+			}
+		})
+	}
+}
+
+func TestLoadConfig_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		message string
+	}{
+		{"Unknown provider", "profiles:\n  a: {provider: mistral, token: t}\n", `unknown provider "mistral"`},
+		{"Unknown field", "profiles:\n  a: {token: t, modle: gpt-4o}\n", "modle"},
+		{"Missing variable", "profiles:\n  a: {token: '${PROFILE_TEST_UNSET}'}\n", "PROFILE_TEST_UNSET is not set"},
+		{"Missing token", "profiles:\n  a: {model: gpt-4o}\n", errorsMissingToken.Error()},
+		{"Missing model", "profiles:\n  a: {provider: anthropic, token: t}\n", errorsMissingModel.Error()},
+		{"Azure without URL", "profiles:\n  a: {provider: azure, token: t}\n", "azure requires base_url"},
+		{"Temperature", "profiles:\n  a: {token: t, temperature: 3}\n", "temperature 3 is out of range"},
+		{"Top P", "profiles:\n  a: {token: t, top_p: 1.5}\n", "top_p 1.5 is out of range"},
+		{"Timeout", "profiles:\n  a: {token: t, timeout: soon}\n", `invalid timeout "soon"`},
+		{"Base URL", "profiles:\n  a: {token: t, base_url: 'localhost:8080'}\n", "is not an http(s) URL"},
+		{"Default", "default: b\nprofiles:\n  a: {token: t}\n", `default profile "b" not found`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfig(writeConfig(t, "config.yaml", tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("Expected error containing %q, got: %v", tt.message, err)
+			}
+		})
+	}
+
+	// Every invalid profile is reported at once.
+	_, err := LoadConfig(writeConfig(t, "config.yaml", "profiles:\n  a: {token: t, top_p: 2}\n  b: {provider: x}\n"))
+	if err == nil || !strings.Contains(err.Error(), `profile "a"`) || !strings.Contains(err.Error(), `profile "b"`) {
+		t.Errorf("Expected errors for both profiles, got: %v", err)
+	}
+
+	if _, err := LoadConfig(writeConfig(t, "config.ini", "")); err == nil {
+		t.Error("Expected error for an unsupported format, got nil")
+	}
+}
+
+func TestNewFromProfile(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer sk-profile" || r.Header.Get("X-Team") != "search" {
+			t.Errorf("Expected the profile token and headers, got %v", r.Header)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), `"model":"gpt-4o-mini"`) {
+			t.Errorf("Expected the explicit model to win, got %s", body)
+		}
+		_, _ = io.WriteString(w, `{"choices":[{"message":{"role":"assistant","content":"Hello"},"finish_reason":"stop"}]}`)
+	}))
+	defer srv.Close()
+
+	t.Setenv("PROFILE_TEST_KEY", "sk-profile")
+	t.Setenv(ConfigEnv, writeConfig(t, "config.yml", `
+profiles:
+  only:
+    base_url: `+srv.URL+`
+    token: ${PROFILE_TEST_KEY}
+    model: gpt-4o
+    name: proxy
+    headers: {X-Team: search}
+`))
+
+	client, err := NewFromProfile("", WithModel("gpt-4o-mini"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	resp, err := client.Completion(context.Background(), "", "hi")
+	if err != nil {
+		t.Fatalf("Completion failed: %v", err)
+	}
+	if resp.Content != "Hello" || resp.Provider != "proxy" {
+		t.Errorf("Expected the profile client, got %+v", resp)
+	}
+}
+
+func TestProfile_Temperature(t *testing.T) {
+	zero, warm := float32(0), float32(0.7)
+	tests := []struct {
+		name        string
+		temperature *float32
+		expected    float32
+	}{
+		{"Unset", nil, defaultTemperature},
+		{"Zero", &zero, zeroTemperature},
+		{"Warm", &warm, 0.7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Profile{Token: "t", Temperature: tt.temperature}
+			if err := p.Validate(); err != nil {
+				t.Fatalf("Expected a valid profile, got: %v", err)
+			}
+			if got := newConfig(p.Options()...).temperature; got != tt.expected {
+				t.Errorf("Expected effective temperature %g, got %g", tt.expected, got)
+			}
+		})
+	}
+}
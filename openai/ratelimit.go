@@ -0,0 +1,128 @@
+package openai
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// rateLimiter throttles requests to stay within a requests-per-minute and
+// tokens-per-minute budget. It is safe for concurrent use.
+//
+// Each call reserves its share immediately, which may drive a bucket negative;
+// the caller then sleeps until the bucket has refilled. Reserving up front
+// keeps waiting callers in arrival order.
+type rateLimiter struct {
+	mu       sync.Mutex
+	requests *bucket
+	tokens   *bucket
+}
+
+// reservation is the share of the budget taken by a single call.
+type reservation struct {
+	limiter *rateLimiter
+	tokens  int
+}
+
+// bucket is a token bucket refilled continuously at capacity per minute.
+type bucket struct {
+	capacity float64
+	level    float64
+	last     time.Time
+}
+
+// newRateLimiter returns a limiter for the given budgets, or nil when both are unlimited.
+func newRateLimiter(rpm, tpm int) *rateLimiter {
+	if rpm <= 0 && tpm <= 0 {
+		return nil
+	}
+	return &rateLimiter{
+		requests: newBucket(rpm),
+		tokens:   newBucket(tpm),
+	}
+}
+
+func newBucket(perMinute int) *bucket {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &bucket{
+		capacity: float64(perMinute),
+		level:    float64(perMinute),
+		last:     time.Now(),
+	}
+}
+
+// take removes n units and returns how long until the bucket is no longer in debt.
+func (b *bucket) take(now time.Time, n float64) time.Duration {
+	if b == nil {
+		return 0
+	}
+	b.refill(now)
+	b.level -= n
+	if b.level >= 0 {
+		return 0
+	}
+	return time.Duration(-b.level / b.capacity * float64(time.Minute))
+}
+
+// give returns n units to the bucket; a negative n takes them.
+func (b *bucket) give(now time.Time, n float64) {
+	if b == nil {
+		return
+	}
+	b.refill(now)
+	b.level = min(b.level+n, b.capacity)
+}
+
+func (b *bucket) refill(now time.Time) {
+	elapsed := now.Sub(b.last)
+	b.last = now
+	b.level = min(b.level+elapsed.Minutes()*b.capacity, b.capacity)
+}
+
+// wait blocks until one request and the estimated number of tokens fit in the budget.
+// A nil limiter never blocks.
+func (l *rateLimiter) wait(ctx context.Context, tokens int) (*reservation, error) {
+	if l == nil {
+		return nil, nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	delay := max(l.requests.take(now, 1), l.tokens.take(now, float64(tokens)))
+	l.mu.Unlock()
+
+	r := &reservation{limiter: l, tokens: tokens}
+	if delay <= 0 {
+		return r, nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		// The request is never sent, so give its share back.
+		l.mu.Lock()
+		now = time.Now()
+		l.requests.give(now, 1)
+		l.tokens.give(now, float64(tokens))
+		l.mu.Unlock()
+		return nil, ctx.Err()
+	case <-timer.C:
+		return r, nil
+	}
+}
+
+// settle corrects the token estimate with the usage reported by the provider.
+// Failed calls get their estimate refunded; successful calls that report no usage keep it.
+func (r *reservation) settle(usage openai.Usage, err error) {
+	if r == nil || (err == nil && usage.TotalTokens == 0) {
+		return
+	}
+	r.limiter.mu.Lock()
+	r.limiter.tokens.give(time.Now(), float64(r.tokens-usage.TotalTokens))
+	r.limiter.mu.Unlock()
+}
@@ -0,0 +1,131 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	openaisdk "github.com/sashabaranov/go-openai"
+)
+
+func TestNewRateLimiter_Unlimited(t *testing.T) {
+	if l := newRateLimiter(0, 0); l != nil {
+		t.Error("Expected nil limiter when both budgets are unlimited")
+	}
+
+	// A nil limiter never blocks.
+	var l *rateLimiter
+	res, err := l.wait(context.Background(), 1000)
+	if err != nil || res != nil {
+		t.Errorf("Expected (nil, nil), got (%v, %v)", res, err)
+	}
+	res.settle(openaisdk.Usage{TotalTokens: 10}, nil)
+}
+
+func TestBucket_TakeAndRefill(t *testing.T) {
+	now := time.Now()
+	b := &bucket{capacity: 60, level: 60, last: now}
+
+	if d := b.take(now, 60); d != 0 {
+		t.Errorf("Expected no delay for a full bucket, got %v", d)
+	}
+
+	if d := b.take(now, 30); d != 30*time.Second {
+		t.Errorf("Expected 30s delay for 30 units of debt at 60/min, got %v", d)
+	}
+
+	b.refill(now.Add(time.Minute))
+	if b.level != 30 {
+		t.Errorf("Expected level 30 after one minute of refill, got %f", b.level)
+	}
+
+	b.refill(now.Add(time.Hour))
+	if b.level != b.capacity {
+		t.Errorf("Expected refill to be capped at capacity %f, got %f", b.capacity, b.level)
+	}
+}
+
+func TestRateLimiter_WaitBlocksAndRefunds(t *testing.T) {
+	l := newRateLimiter(1, 0)
+
+	if _, err := l.wait(context.Background(), 0); err != nil {
+		t.Fatalf("Expected first request to pass, got: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := l.wait(ctx, 0)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected deadline exceeded, got: %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected wait to stop at the deadline, took %v", elapsed)
+	}
+
+	// The cancelled request must not keep its reservation.
+	if l.requests.level < -0.01 {
+		t.Errorf("Expected cancelled reservation to be refunded, level is %f", l.requests.level)
+	}
+}
+
+func TestReservation_Settle(t *testing.T) {
+	tests := []struct {
+		name     string
+		usage    openaisdk.Usage
+		err      error
+		expected float64
+	}{
+		{"Correct to actual usage", openaisdk.Usage{TotalTokens: 300}, nil, 700},
+		{"Keep estimate without usage", openaisdk.Usage{}, nil, 900},
+		{"Refund failed call", openaisdk.Usage{}, errors.New("boom"), 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newRateLimiter(0, 1000)
+			res, err := l.wait(context.Background(), 100)
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+
+			res.settle(tt.usage, tt.err)
+
+			// Allow for the refill during the test itself.
+			if l.tokens.level < tt.expected-1 || l.tokens.level > tt.expected+1 {
+				t.Errorf("Expected token level %f, got %f", tt.expected, l.tokens.level)
+			}
+		})
+	}
+}
+
+func TestRateLimiter_Concurrent(t *testing.T) {
+	l := newRateLimiter(1000, 100000)
+
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := l.wait(context.Background(), 10)
+			if err != nil {
+				t.Errorf("Expected no error, got: %v", err)
+				return
+			}
+			res.settle(openaisdk.Usage{TotalTokens: 20}, nil)
+		}()
+	}
+	wg.Wait()
+
+	if l.requests.level > 951 || l.requests.level < 949 {
+		t.Errorf("Expected about 950 requests left, got %f", l.requests.level)
+	}
+
+	if l.tokens.level > 99001 || l.tokens.level < 98999 {
+		t.Errorf("Expected about 99000 tokens left, got %f", l.tokens.level)
+	}
+}
@@ -0,0 +1,368 @@
+// Package recorder records HTTP interactions with an API to cassette files and replays
+// them, so tests of code built on the client run offline and deterministically.
+//
+// A Recorder is an http.RoundTripper; plug it into the client with openai.WithHTTPTransport.
+// Credentials are scrubbed before a cassette is written, and streamed (SSE) responses are
+// stored whole and replayed chunk for chunk.
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Mode selects whether a Recorder replays or records interactions.
+type Mode int
+
+const (
+	// ModeAuto replays the cassette if the file exists and records a new one otherwise.
+	ModeAuto Mode = iota
+	// ModeReplay only replays; a request without a recorded interaction fails.
+	ModeReplay
+	// ModeRecord sends every request and overwrites the cassette on Stop.
+	ModeRecord
+)
+
+// ErrNoInteraction is returned in replay mode for a request that was not recorded.
+var ErrNoInteraction = errors.New("recorder: no recorded interaction matches the request")
+
+// scrubbed replaces credentials in cassettes.
+const scrubbed = "REDACTED"
+
+// credentialHeaders are removed from cassettes regardless of the scrubbers.
+var credentialHeaders = []string{
+	"Authorization", "Api-Key", "X-Api-Key", "X-Goog-Api-Key",
+	"Openai-Organization", "Openai-Project", "Cookie", "Set-Cookie", "Proxy-Authorization",
+}
+
+// credentialHints are fragments of request header names that carry secrets, as in
+// openai.LoggingTransport. They catch credentials added with openai.WithHeaders.
+var credentialHints = []string{"auth", "key", "token", "secret", "password", "cookie", "signature", "session"}
+
+// credentialParams are query parameters replaced in cassettes.
+var credentialParams = []string{"key", "api_key", "api-key", "access_token"}
+
+// Cassette is the file format of recorded interactions.
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded request and its response.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is the recorded part of an HTTP request.
+type Request struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+}
+
+// Response is the recorded part of an HTTP response.
+type Response struct {
+	Status  int         `json:"status"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+}
+
+// Option configures a Recorder.
+type Option func(*Recorder)
+
+// WithMode sets whether the recorder replays or records. Defaults to ModeAuto.
+func WithMode(mode Mode) Option {
+	return func(r *Recorder) {
+		r.mode = mode
+	}
+}
+
+// WithRealTransport sets the transport of requests that are recorded.
+// Defaults to http.DefaultTransport.
+func WithRealTransport(rt http.RoundTripper) Option {
+	return func(r *Recorder) {
+		r.real = rt
+	}
+}
+
+// WithScrubber adds a function that edits every interaction before it is saved,
+// e.g. to remove account identifiers from bodies. Credential headers and query
+// parameters are always scrubbed.
+func WithScrubber(fn func(*Interaction)) Option {
+	return func(r *Recorder) {
+		r.scrubbers = append(r.scrubbers, fn)
+	}
+}
+
+// Recorder is an http.RoundTripper that records or replays a cassette.
+// It is safe for concurrent use.
+type Recorder struct {
+	path      string
+	mode      Mode
+	real      http.RoundTripper
+	scrubbers []func(*Interaction)
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+	dirty    bool
+}
+
+// New returns a Recorder for the cassette at path, loading it unless recording.
+func New(path string, opts ...Option) (*Recorder, error) {
+	r := &Recorder{path: path, real: http.DefaultTransport}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if r.mode == ModeAuto {
+		r.mode = ModeRecord
+		if _, err := os.Stat(path); err == nil {
+			r.mode = ModeReplay
+		}
+	}
+	if r.mode == ModeRecord {
+		return r, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("recorder: %w", err)
+	}
+	if err := json.Unmarshal(data, &r.cassette); err != nil {
+		return nil, fmt.Errorf("recorder: invalid cassette %s: %w", path, err)
+	}
+	r.used = make([]bool, len(r.cassette.Interactions))
+	return r, nil
+}
+
+// Mode returns the mode in effect, resolving ModeAuto.
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Stop writes the recorded interactions to the cassette. It does nothing when replaying.
+func (r *Recorder) Stop() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.mode != ModeRecord || !r.dirty {
+		return nil
+	}
+	data, err := json.MarshalIndent(&r.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(r.path, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	r.dirty = false
+	return nil
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+	if r.mode == ModeReplay {
+		return r.replay(req, body)
+	}
+	return r.record(req, body)
+}
+
+// replay answers with the first unused interaction that matches the request,
+// or with the last matching one once all have been used.
+func (r *Recorder) replay(req *http.Request, body []byte) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	found := -1
+	for i, in := range r.cassette.Interactions {
+		if !matches(in.Request, req, body) {
+			continue
+		}
+		found = i
+		if !r.used[i] {
+			break
+		}
+	}
+	if found < 0 {
+		return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, req.Method, req.URL.Path)
+	}
+	r.used[found] = true
+
+	rec := r.cassette.Interactions[found].Response
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rec.Status, http.StatusText(rec.Status)),
+		StatusCode:    rec.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        rec.Headers.Clone(),
+		Body:          io.NopCloser(strings.NewReader(rec.Body)),
+		ContentLength: int64(len(rec.Body)),
+		Request:       req,
+	}, nil
+}
+
+// record sends the request and stores the interaction once its body has been read,
+// so streamed responses are delivered to the caller as they arrive.
+func (r *Recorder) record(req *http.Request, body []byte) (*http.Response, error) {
+	resp, err := r.real.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	in := &Interaction{
+		Request: Request{
+			Method:  req.Method,
+			URL:     req.URL.String(),
+			Headers: req.Header.Clone(),
+			Body:    string(body),
+		},
+		Response: Response{
+			Status:  resp.StatusCode,
+			Headers: resp.Header.Clone(),
+		},
+	}
+	resp.Body = &recordingBody{ReadCloser: resp.Body, done: func(data []byte) {
+		in.Response.Body = string(data)
+		r.add(in)
+	}}
+	return resp, nil
+}
+
+func (r *Recorder) add(in *Interaction) {
+	scrub(in)
+	for _, fn := range r.scrubbers {
+		fn(in)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, in)
+	r.dirty = true
+}
+
+// recordingBody keeps a copy of what is read and hands it over on EOF or Close.
+type recordingBody struct {
+	io.ReadCloser
+	buf  bytes.Buffer
+	done func([]byte)
+	once sync.Once
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.buf.Write(p[:n])
+	if errors.Is(err, io.EOF) {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	// Keep what the caller did not read, so the cassette holds the whole response.
+	_, _ = b.buf.ReadFrom(b.ReadCloser)
+	b.finish()
+	return b.ReadCloser.Close()
+}
+
+func (b *recordingBody) finish() {
+	b.once.Do(func() { b.done(b.buf.Bytes()) })
+}
+
+// readBody returns the request body, leaving it readable for the real transport.
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// matches compares method, path, query and body. The host is ignored so cassettes
+// recorded against one base URL replay against another, and JSON bodies are
+// compared by value.
+func matches(rec Request, req *http.Request, body []byte) bool {
+	if rec.Method != req.Method {
+		return false
+	}
+	u, err := url.Parse(rec.URL)
+	if err != nil || u.Path != req.URL.Path {
+		return false
+	}
+	if scrubQuery(u.Query()).Encode() != scrubQuery(req.URL.Query()).Encode() {
+		return false
+	}
+	return sameBody([]byte(rec.Body), body)
+}
+
+func sameBody(a, b []byte) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	var va, vb any
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	ja, _ := json.Marshal(va)
+	jb, _ := json.Marshal(vb)
+	return bytes.Equal(ja, jb)
+}
+
+// scrub removes credentials from an interaction.
+func scrub(in *Interaction) {
+	for name := range in.Request.Headers {
+		if isCredential(name) {
+			in.Request.Headers.Set(name, scrubbed)
+		}
+	}
+	// Response headers such as x-ratelimit-remaining-tokens match the hints but carry no secret.
+	for _, name := range credentialHeaders {
+		in.Response.Headers.Del(name)
+	}
+	if u, err := url.Parse(in.Request.URL); err == nil && u.RawQuery != "" {
+		u.RawQuery = scrubQuery(u.Query()).Encode()
+		in.Request.URL = u.String()
+	}
+}
+
+// isCredential reports whether a request header name looks like it carries a secret.
+func isCredential(name string) bool {
+	if slices.ContainsFunc(credentialHeaders, func(h string) bool { return strings.EqualFold(h, name) }) {
+		return true
+	}
+	name = strings.ToLower(name)
+	return slices.ContainsFunc(credentialHints, func(hint string) bool { return strings.Contains(name, hint) })
+}
+
+func scrubQuery(q url.Values) url.Values {
+	for _, name := range credentialParams {
+		if q.Has(name) {
+			q.Set(name, scrubbed)
+		}
+	}
+	return q
+}
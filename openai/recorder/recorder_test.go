@@ -0,0 +1,174 @@
+package recorder
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const sseBody = "data: {\"choices\":[{\"delta\":{\"content\":\"Hi\"}}]}\n\ndata: [DONE]\n\n"
+
+func newAPIServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), `"stream":true`) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = io.WriteString(w, sseBody)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=secret")
+		_, _ = io.WriteString(w, `{"echo":`+string(body)+`}`)
+	}))
+}
+
+func send(t *testing.T, rt http.RoundTripper, url, body string) (int, string, error) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer sk-secret")
+
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read body: %v", err)
+	}
+	return resp.StatusCode, string(data), nil
+}
+
+func TestRecorder_RecordAndReplay(t *testing.T) {
+	srv := newAPIServer(t)
+	path := filepath.Join(t.TempDir(), "cassettes", "chat.json")
+
+	rec, err := New(path)
+	if err != nil {
+		t.Fatalf("Failed to create recorder: %v", err)
+	}
+	if rec.Mode() != ModeRecord {
+		t.Fatalf("Expected ModeRecord without a cassette, got %v", rec.Mode())
+	}
+
+	if _, _, err := send(t, rec, srv.URL+"/v1/chat/completions?key=secret", `{"a":1,"b":2}`); err != nil {
+		t.Fatalf("Failed to record: %v", err)
+	}
+	if _, _, err := send(t, rec, srv.URL+"/v1/chat/completions", `{"stream":true}`); err != nil {
+		t.Fatalf("Failed to record stream: %v", err)
+	}
+	if err := rec.Stop(); err != nil {
+		t.Fatalf("Failed to save cassette: %v", err)
+	}
+	srv.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read cassette: %v", err)
+	}
+	for _, secret := range []string{"sk-secret", "session=secret", "key=secret"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("Expected %s to be scrubbed from the cassette", secret)
+		}
+	}
+
+	// The server is gone: everything below is replayed, from another host.
+	rec, err = New(path)
+	if err != nil {
+		t.Fatalf("Failed to load cassette: %v", err)
+	}
+	if rec.Mode() != ModeReplay {
+		t.Fatalf("Expected ModeReplay with a cassette, got %v", rec.Mode())
+	}
+
+	tests := []struct {
+		name     string
+		url      string
+		body     string
+		expected string
+		err      error
+	}{
+		{"JSON body compared by value", "http://replay.test/v1/chat/completions?key=other", `{"b":2, "a":1}`, `{"echo":{"a":1,"b":2}}`, nil},
+		{"Stream", "http://replay.test/v1/chat/completions", `{"stream":true}`, sseBody, nil},
+		{"Other body", "http://replay.test/v1/chat/completions", `{"a":2}`, "", ErrNoInteraction},
+		{"Other path", "http://replay.test/v1/embeddings", `{"a":1,"b":2}`, "", ErrNoInteraction},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body, err := send(t, rec, tt.url, tt.body)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Expected error %v, got %v", tt.err, err)
+			}
+			if tt.err == nil && (status != http.StatusOK || body != tt.expected) {
+				t.Errorf("Expected 200 %q, got %d %q", tt.expected, status, body)
+			}
+		})
+	}
+}
+
+func TestRecorder_ReplayMissingCassette(t *testing.T) {
+	if _, err := New(filepath.Join(t.TempDir(), "missing.json"), WithMode(ModeReplay)); err == nil {
+		t.Error("Expected error for a missing cassette, got nil")
+	}
+}
+
+func TestRecorder_WithScrubber(t *testing.T) {
+	srv := newAPIServer(t)
+	defer srv.Close()
+	path := filepath.Join(t.TempDir(), "scrubbed.json")
+
+	rec, err := New(path, WithScrubber(func(in *Interaction) {
+		in.Response.Body = strings.ReplaceAll(in.Response.Body, "user-42", "user-x")
+	}))
+	if err != nil {
+		t.Fatalf("Failed to create recorder: %v", err)
+	}
+	if _, _, err := send(t, rec, srv.URL+"/v1/chat/completions", `{"user":"user-42"}`); err != nil {
+		t.Fatalf("Failed to record: %v", err)
+	}
+	if err := rec.Stop(); err != nil {
+		t.Fatalf("Failed to save cassette: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), `user-x`) {
+		t.Errorf("Expected the scrubber to edit the response, got %s", data)
+	}
+}
+
+func TestScrub_CustomHeaders(t *testing.T) {
+	in := &Interaction{
+		Request: Request{Headers: http.Header{
+			"X-Auth-Token":      {"gw-secret"},
+			"X-Portkey-Api-Key": {"pk-secret"},
+			"X-Tenant":          {"acme"},
+		}},
+		Response: Response{Headers: http.Header{
+			"Set-Cookie":                   {"session=secret"},
+			"X-Ratelimit-Remaining-Tokens": {"149000"},
+		}},
+	}
+	scrub(in)
+
+	for _, name := range []string{"X-Auth-Token", "X-Portkey-Api-Key"} {
+		if got := in.Request.Headers.Get(name); got != scrubbed {
+			t.Errorf("Expected %s to be scrubbed, got %q", name, got)
+		}
+	}
+	if got := in.Request.Headers.Get("X-Tenant"); got != "acme" {
+		t.Errorf("Expected X-Tenant to be kept, got %q", got)
+	}
+	if in.Response.Headers.Get("Set-Cookie") != "" || in.Response.Headers.Get("X-Ratelimit-Remaining-Tokens") != "149000" {
+		t.Errorf("Expected only the cookie to be removed from the response, got %v", in.Response.Headers)
+	}
+}
@@ -0,0 +1,200 @@
+package openai
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRetryBaseDelay = 500 * time.Millisecond
+	defaultRetryMaxDelay  = 30 * time.Second
+
+	// maxPeekBody bounds how much of an error body is read to classify a failure.
+	maxPeekBody = 64 << 10
+)
+
+// RetryTransport is an http.RoundTripper that retries rate-limited and transient failures
+// with exponential backoff and jitter. Only failures where the request was not processed
+// are retried: 408, 429, 502, 503 and 504 gateway errors and connection failures before
+// sending. A 500 may come after the completion ran and was billed, so it is only retried
+// when the server sends x-should-retry: true.
+// Server hints (Retry-After, retry-after-ms and x-ratelimit-reset-*) take precedence over
+// the computed backoff; a hint longer than MaxDelay or the context deadline ends retrying.
+type RetryTransport struct {
+	Origin     http.RoundTripper
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	for attempt := 0; ; attempt++ {
+		r, err := rewindRequest(req, attempt)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := t.Origin.RoundTrip(r)
+		if attempt >= t.MaxRetries || !shouldRetry(req, resp, err) {
+			return resp, err
+		}
+
+		delay, ok := t.delay(attempt, resp)
+		if deadline, has := ctx.Deadline(); has && time.Until(deadline) < delay {
+			ok = false
+		}
+		if !ok {
+			return resp, err
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxPeekBody))
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// delay returns how long to wait before the next attempt and whether retrying is allowed.
+func (t *RetryTransport) delay(attempt int, resp *http.Response) (time.Duration, bool) {
+	maxDelay := t.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = defaultRetryMaxDelay
+	}
+
+	if resp != nil {
+		if hint, ok := retryAfter(resp.Header, resp.StatusCode == http.StatusTooManyRequests); ok {
+			return hint, hint <= maxDelay
+		}
+	}
+
+	base := t.BaseDelay
+	if base <= 0 {
+		base = defaultRetryBaseDelay
+	}
+	d := base << min(attempt, 30)
+	if d <= 0 || d > maxDelay {
+		d = maxDelay
+	}
+	// Equal jitter: wait at least half of the backoff so retries never collapse to zero.
+	half := d / 2
+	return half + rand.N(half+1), true
+}
+
+// rewindRequest returns a copy of req for the given attempt. The original body is
+// used for the first attempt and replayed through GetBody afterwards.
+func rewindRequest(req *http.Request, attempt int) (*http.Request, error) {
+	r := req.Clone(req.Context())
+	if attempt == 0 || req.Body == nil || req.Body == http.NoBody {
+		return r, nil
+	}
+	if req.GetBody == nil {
+		return nil, errors.New("retry: request body cannot be replayed")
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	r.Body = body
+	return r, nil
+}
+
+// shouldRetry reports whether a failed attempt is safe to repeat.
+func shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if err != nil {
+		if req.Context().Err() != nil {
+			return false
+		}
+		// Only dial failures guarantee the request never reached the server.
+		var opErr *net.OpError
+		return errors.As(err, &opErr) && opErr.Op == "dial"
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+
+	if v, ok := serverShouldRetry(resp.Header); ok {
+		return v
+	}
+	// An exhausted quota does not recover by waiting.
+	if resp.StatusCode == http.StatusTooManyRequests && isQuotaExhausted(resp) {
+		return false
+	}
+	return retryableStatus(resp.StatusCode)
+}
+
+// serverShouldRetry returns the x-should-retry header, by which OpenAI tells clients
+// explicitly whether a retry makes sense, and whether the response has one.
+func serverShouldRetry(h http.Header) (bool, bool) {
+	v, err := strconv.ParseBool(h.Get("x-should-retry"))
+	return v, err == nil
+}
+
+// retryableStatus reports whether a response with the status was not processed and
+// may be retried. A 500 is left out, as it may come after the completion was billed.
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// isQuotaExhausted peeks at the error body for an insufficient_quota code,
+// leaving the body readable for the caller.
+func isQuotaExhausted(resp *http.Response) bool {
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPeekBody))
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return err == nil && bytes.Contains(body, []byte("insufficient_quota"))
+}
+
+// retryAfter extracts the wait time requested by the server, if any.
+// The rate limit reset headers are only meaningful for rate-limited responses.
+func retryAfter(h http.Header, rateLimited bool) (time.Duration, bool) {
+	if ms, err := strconv.ParseFloat(h.Get("retry-after-ms"), 64); err == nil && ms >= 0 {
+		return time.Duration(ms * float64(time.Millisecond)), true
+	}
+	if v := h.Get("Retry-After"); v != "" {
+		if secs, err := strconv.ParseFloat(v, 64); err == nil && secs >= 0 {
+			return time.Duration(secs * float64(time.Second)), true
+		}
+		if at, err := http.ParseTime(v); err == nil {
+			return max(time.Until(at), 0), true
+		}
+	}
+
+	if !rateLimited {
+		return 0, false
+	}
+
+	// x-ratelimit-reset-* use Go-like durations such as "1s", "6m0s" or "20ms".
+	var (
+		longest time.Duration
+		found   bool
+	)
+	for _, key := range []string{"x-ratelimit-reset-requests", "x-ratelimit-reset-tokens"} {
+		if d, err := time.ParseDuration(strings.TrimSpace(h.Get(key))); err == nil {
+			longest = max(longest, d)
+			found = true
+		}
+	}
+	return longest, found
+}
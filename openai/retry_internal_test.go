@@ -0,0 +1,51 @@
+package openai
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name        string
+		header      http.Header
+		rateLimited bool
+		expected    time.Duration
+		found       bool
+	}{
+		{"None", http.Header{}, true, 0, false},
+		{"Milliseconds", http.Header{"Retry-After-Ms": {"250"}}, false, 250 * time.Millisecond, true},
+		{"Seconds", http.Header{"Retry-After": {"2"}}, false, 2 * time.Second, true},
+		{"Reset headers", http.Header{
+			"X-Ratelimit-Reset-Requests": {"1s"},
+			"X-Ratelimit-Reset-Tokens":   {"6m0s"},
+		}, true, 6 * time.Minute, true},
+		{"Reset headers ignored when not rate limited", http.Header{"X-Ratelimit-Reset-Requests": {"1s"}}, false, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, found := retryAfter(tt.header, tt.rateLimited)
+			if found != tt.found || d != tt.expected {
+				t.Errorf("Expected (%v, %v), got (%v, %v)", tt.expected, tt.found, d, found)
+			}
+		})
+	}
+}
+
+func TestRetryTransport_Backoff(t *testing.T) {
+	rt := &RetryTransport{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	for attempt := range 6 {
+		d, ok := rt.delay(attempt, nil)
+		if !ok {
+			t.Fatalf("Expected retry to be allowed for attempt %d", attempt)
+		}
+
+		ceiling := min(100*time.Millisecond<<attempt, time.Second)
+		if d < ceiling/2 || d > ceiling {
+			t.Errorf("Attempt %d: expected delay in [%v, %v], got %v", attempt, ceiling/2, ceiling, d)
+		}
+	}
+}
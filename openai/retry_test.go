@@ -0,0 +1,147 @@
+package openai_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	openaisdk "github.com/sashabaranov/go-openai"
+
+	"github.com/ysicing/openai/openai"
+	"github.com/ysicing/openai/openai/openaitest"
+)
+
+// newRetryClient returns a client of srv that retries up to maxRetries times with a short backoff.
+func newRetryClient(srv *openaitest.Server, maxRetries int) *openai.Client {
+	return srv.Client(openai.WithRetry(maxRetries), openai.WithRetryBackoff(time.Millisecond, 10*time.Millisecond))
+}
+
+// failure returns a reply that fails with the status, error code and response headers.
+func failure(status int, code string, header http.Header) openaitest.Reply {
+	reply := openaitest.Error(status, code, "failed with "+http.StatusText(status))
+	reply.Header = header
+	return reply
+}
+
+// failAlways makes every chat completion request to srv fail with the reply.
+func failAlways(srv *openaitest.Server, reply openaitest.Reply) {
+	srv.OnChat(func(openaisdk.ChatCompletionRequest) openaitest.Reply { return reply })
+}
+
+func TestRetryTransport_RetriesServerErrors(t *testing.T) {
+	srv := openaitest.NewServer(t)
+	unavailable := failure(http.StatusServiceUnavailable, "", nil)
+	srv.Reply(unavailable, unavailable, openaitest.Text("ok"))
+
+	resp, err := newRetryClient(srv, 3).Completion(context.Background(), "", "hi")
+	if err != nil {
+		t.Fatalf("Completion failed: %v", err)
+	}
+
+	if resp.Content != "ok" {
+		t.Errorf("Expected content 'ok', got '%s'", resp.Content)
+	}
+
+	requests := srv.Requests()
+	if len(requests) != 3 {
+		t.Fatalf("Expected 3 attempts, got %d", len(requests))
+	}
+
+	for _, r := range requests[1:] {
+		if !bytes.Equal(r.Body, requests[0].Body) {
+			t.Errorf("Expected replayed body '%s', got '%s'", requests[0].Body, r.Body)
+		}
+	}
+}
+
+func TestRetryTransport_RetriesInternalErrorWhenAsked(t *testing.T) {
+	srv := openaitest.NewServer(t)
+	srv.Reply(failure(http.StatusInternalServerError, "", http.Header{"X-Should-Retry": {"true"}}), openaitest.Text("ok"))
+
+	_, err := newRetryClient(srv, 3).Completion(context.Background(), "", "hi")
+	if n := len(srv.Requests()); err != nil || n != 2 {
+		t.Errorf("Expected success on the second attempt, got %v after %d attempts", err, n)
+	}
+}
+
+func TestRetryTransport_GivesUpAfterMaxRetries(t *testing.T) {
+	srv := openaitest.NewServer(t)
+	failAlways(srv, failure(http.StatusBadGateway, "", nil))
+
+	_, err := newRetryClient(srv, 2).Completion(context.Background(), "", "hi")
+
+	var apiErr *openai.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadGateway {
+		t.Errorf("Expected status 502, got %v", err)
+	}
+
+	if n := len(srv.Requests()); n != 3 {
+		t.Errorf("Expected 3 attempts, got %d", n)
+	}
+}
+
+func TestRetryTransport_NotRetried(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply openaitest.Reply
+	}{
+		{"Bad request", failure(http.StatusBadRequest, "", nil)},
+		{"Unauthorized", failure(http.StatusUnauthorized, "", nil)},
+		{"Insufficient quota", failure(http.StatusTooManyRequests, "insufficient_quota", nil)},
+		{"Server says no", failure(http.StatusServiceUnavailable, "", http.Header{"X-Should-Retry": {"false"}})},
+		{"Internal server error", failure(http.StatusInternalServerError, "", nil)},
+		{"Retry-After beyond max delay", openaitest.RateLimited},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := openaitest.NewServer(t)
+			failAlways(srv, tt.reply)
+
+			_, err := newRetryClient(srv, 3).Completion(context.Background(), "", "hi")
+
+			if n := len(srv.Requests()); n != 1 {
+				t.Errorf("Expected 1 attempt, got %d", n)
+			}
+
+			// The error body stays readable after the retry decision.
+			if err == nil || !strings.Contains(err.Error(), tt.reply.Error.Message) {
+				t.Errorf("Expected error '%s', got %v", tt.reply.Error.Message, err)
+			}
+		})
+	}
+}
+
+func TestRetryTransport_HonoursRetryAfter(t *testing.T) {
+	srv := openaitest.NewServer(t)
+	srv.Reply(failure(http.StatusTooManyRequests, "", http.Header{"Retry-After-Ms": {"5"}}), openaitest.Text("ok"))
+
+	if _, err := newRetryClient(srv, 3).Completion(context.Background(), "", "hi"); err != nil {
+		t.Fatalf("Completion failed: %v", err)
+	}
+
+	if n := len(srv.Requests()); n != 2 {
+		t.Errorf("Expected 2 attempts, got %d", n)
+	}
+}
+
+func TestRetryTransport_RespectsDeadline(t *testing.T) {
+	srv := openaitest.NewServer(t)
+	failAlways(srv, failure(http.StatusServiceUnavailable, "", nil))
+	client := srv.Client(openai.WithRetry(5), openai.WithRetryBackoff(time.Second, time.Second))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	if _, err := client.Completion(ctx, "", "hi"); err == nil {
+		t.Error("Expected error, got nil")
+	}
+
+	if n := len(srv.Requests()); n != 1 {
+		t.Errorf("Expected no retry past the deadline, got %d attempts", n)
+	}
+}
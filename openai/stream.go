@@ -0,0 +1,222 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+var errStreamClosed = errors.New("stream closed before completion")
+
+// Delta is an incremental piece of a streamed chat completion.
+// The first delta usually carries only the Role of the message.
+type Delta struct {
+	Role         string
+	Content      string
+	FinishReason openai.FinishReason
+}
+
+// Stream is a chat completion that is delivered incrementally.
+// Deltas yields the pieces as they arrive, and the final Response
+// (content, usage and finish reason) is assembled from the same chunks
+// as they are read, by Deltas or by Response.
+// A Stream must be consumed by a single goroutine.
+type Stream struct {
+	ctx    context.Context
+	stream ChatCompletionStream
+	// onDone receives the final response once the stream has ended.
+	onDone func(openai.ChatCompletionResponse, error)
+	// wrapErr converts read errors into an *Error.
+	wrapErr func(error) error
+	// id and model are reported by the chunks.
+	id    string
+	model string
+
+	content strings.Builder
+	resp    Response
+	done    bool
+	err     error
+}
+
+// ChatStream is an API call to create a streamed completion for a list of chat messages.
+func (c *Client) ChatStream(
+	ctx context.Context,
+	messages []openai.ChatCompletionMessage,
+) (*Stream, error) {
+	req := c.buildChatCompletionRequest(messages)
+	req.Stream = true
+	if c.streamUsage {
+		// Ask for a trailing usage chunk so the assembled Response carries token counts.
+		req.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+	}
+
+	ctx, op := c.telemetry.startChat(ctx, req)
+	res, err := c.reserve(ctx, req)
+	if err != nil {
+		op.endChat(ctx, openai.ChatCompletionResponse{}, err)
+		return nil, err
+	}
+	ctx, header := withResponseHeader(ctx)
+	s, err := c.client.CreateChatCompletionStream(ctx, req)
+	if err != nil {
+		err = c.wrapError(err, header)
+		res.settle(openai.Usage{}, err)
+		op.endChat(ctx, openai.ChatCompletionResponse{}, err)
+		return nil, fmt.Errorf("chat completion stream failed: %w", err)
+	}
+	return &Stream{
+		ctx:    ctx,
+		stream: s,
+		wrapErr: func(err error) error {
+			return c.wrapError(err, header)
+		},
+		onDone: func(resp openai.ChatCompletionResponse, err error) {
+			res.settle(resp.Usage, err)
+			op.endChat(ctx, resp, err)
+			c.track(ctx, resp.Model, resp.Usage)
+		},
+		resp: Response{Provider: c.name},
+	}, nil
+}
+
+// CompletionStream is the streaming counterpart of Completion.
+func (c *Client) CompletionStream(
+	ctx context.Context,
+	prompt, content string,
+) (*Stream, error) {
+	return c.ChatStream(ctx, newCompletionMessages(prompt, content))
+}
+
+// Deltas returns an iterator over the incremental pieces of the completion.
+// Iteration stops at the end of the stream, on the first error, or when the
+// caller breaks out of the loop, in which case the stream is closed.
+func (s *Stream) Deltas() iter.Seq2[Delta, error] {
+	return func(yield func(Delta, error) bool) {
+		for !s.done {
+			delta, ok := s.next()
+			if !ok {
+				if s.err != nil {
+					yield(Delta{}, s.err)
+				}
+				return
+			}
+			if !yield(delta, nil) {
+				_ = s.Close()
+				return
+			}
+		}
+	}
+}
+
+// Response returns the assembled response, draining any deltas not read yet.
+func (s *Stream) Response() (*Response, error) {
+	for !s.done {
+		s.next()
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	resp := s.resp
+	resp.Content = s.content.String()
+	return &resp, nil
+}
+
+// Close releases the underlying connection. It is safe to call more than once.
+func (s *Stream) Close() error {
+	if !s.done {
+		s.finish(errStreamClosed)
+	}
+	return s.stream.Close()
+}
+
+// next reads chunks until one carries a role, content or finish reason for the caller.
+// It returns false once the stream is finished or has failed.
+func (s *Stream) next() (Delta, bool) {
+	for {
+		if err := s.ctx.Err(); err != nil {
+			s.finish(err)
+			return Delta{}, false
+		}
+
+		chunk, err := s.stream.Recv()
+		if errors.Is(err, io.EOF) {
+			s.finish(nil)
+			return Delta{}, false
+		}
+		if err != nil {
+			// A cancelled request surfaces as a read error; report the cause instead.
+			if ctxErr := s.ctx.Err(); ctxErr != nil {
+				err = ctxErr
+			} else if s.wrapErr != nil {
+				err = s.wrapErr(err)
+			}
+			s.finish(err)
+			return Delta{}, false
+		}
+
+		if chunk.Usage != nil {
+			s.resp.Usage = *chunk.Usage
+		}
+		if chunk.ID != "" {
+			s.id = chunk.ID
+		}
+		if chunk.Model != "" {
+			s.model = chunk.Model
+		}
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+
+		choice := chunk.Choices[0]
+		delta := Delta{
+			Role:         choice.Delta.Role,
+			Content:      choice.Delta.Content,
+			FinishReason: choice.FinishReason,
+		}
+		s.content.WriteString(delta.Content)
+		if delta.FinishReason != "" {
+			s.resp.FinishReason = delta.FinishReason
+		}
+		if delta.Role == "" && delta.Content == "" && delta.FinishReason == "" {
+			continue
+		}
+		return delta, true
+	}
+}
+
+// finish marks the stream as done and records its terminal error, if any.
+func (s *Stream) finish(err error) {
+	s.done = true
+	s.err = err
+	_ = s.stream.Close()
+	if s.onDone != nil {
+		s.onDone(openai.ChatCompletionResponse{
+			ID:      s.id,
+			Model:   s.model,
+			Choices: []openai.ChatCompletionChoice{{FinishReason: s.resp.FinishReason}},
+			Usage:   s.resp.Usage,
+		}, err)
+	}
+}
+
+// azureStreamUsageVersion is the first Azure OpenAI API version that accepts stream_options.
+const azureStreamUsageVersion = "2024-09-01"
+
+// supportsStreamUsage reports whether the configured endpoint accepts stream_options.
+// Older Azure API versions reject the request with a 400.
+func supportsStreamUsage(cfg *config) bool {
+	if cfg.provider != Azure {
+		return true
+	}
+	version := cfg.apiVersion
+	if version == "" {
+		version = openai.DefaultAzureConfig("", "").APIVersion
+	}
+	// API versions start with their date, e.g. 2024-10-21 or 2024-09-01-preview.
+	return version >= azureStreamUsageVersion
+}
@@ -0,0 +1,184 @@
+package openai_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	openaisdk "github.com/sashabaranov/go-openai"
+
+	"github.com/ysicing/openai/openai"
+	"github.com/ysicing/openai/openai/openaitest"
+)
+
+var helloReply = openaitest.Reply{
+	Chunks: []string{"Hello", ", world"},
+	Usage:  &openaisdk.Usage{PromptTokens: 5, CompletionTokens: 3, TotalTokens: 8},
+}
+
+func TestClient_CompletionStream(t *testing.T) {
+	srv := openaitest.NewServer(t)
+	srv.Reply(helloReply)
+
+	stream, err := srv.Client().CompletionStream(context.Background(), "", "Say hello")
+	if err != nil {
+		t.Fatalf("CompletionStream failed: %v", err)
+	}
+	defer stream.Close()
+
+	var (
+		roles []string
+		parts []string
+	)
+	for delta, err := range stream.Deltas() {
+		if err != nil {
+			t.Fatalf("Unexpected stream error: %v", err)
+		}
+		if delta.Role != "" {
+			roles = append(roles, delta.Role)
+		}
+		if delta.Content != "" {
+			parts = append(parts, delta.Content)
+		}
+	}
+
+	if strings.Join(parts, "|") != "Hello|, world" {
+		t.Errorf("Expected deltas 'Hello|, world', got '%s'", strings.Join(parts, "|"))
+	}
+
+	if len(roles) != 1 || roles[0] != openaisdk.ChatMessageRoleAssistant {
+		t.Errorf("Expected one delta with the assistant role, got %v", roles)
+	}
+
+	resp, err := stream.Response()
+	if err != nil {
+		t.Fatalf("Response failed: %v", err)
+	}
+
+	if resp.Content != "Hello, world" {
+		t.Errorf("Expected content 'Hello, world', got '%s'", resp.Content)
+	}
+
+	if resp.FinishReason != openaisdk.FinishReasonStop {
+		t.Errorf("Expected finish reason 'stop', got '%s'", resp.FinishReason)
+	}
+
+	if resp.Usage.TotalTokens != 8 {
+		t.Errorf("Expected 8 total tokens, got %d", resp.Usage.TotalTokens)
+	}
+
+	req := srv.ChatRequests()[0]
+	if !req.Stream || req.StreamOptions == nil || !req.StreamOptions.IncludeUsage {
+		t.Errorf("Expected a stream with usage requested, got stream %v and options %+v", req.Stream, req.StreamOptions)
+	}
+}
+
+func TestClient_StreamUsage(t *testing.T) {
+	tests := []struct {
+		name  string
+		azure bool
+		opts  []openai.Option
+		usage bool
+	}{
+		{"OpenAI", false, nil, true},
+		{"Opted out", false, []openai.Option{openai.WithStreamUsage(false)}, false},
+		{"Azure before stream_options", true, nil, false},
+		{"Azure default API version", true, []openai.Option{openai.WithApiVersion("")}, false},
+		{"Azure with stream_options", true, []openai.Option{openai.WithApiVersion("2024-10-21")}, true},
+		{"Azure opted in", true, []openai.Option{openai.WithStreamUsage(true)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := openaitest.NewServer(t)
+			srv.Reply(helloReply)
+			client := srv.Client(tt.opts...)
+			if tt.azure {
+				client = srv.AzureClient(tt.opts...)
+			}
+
+			stream, err := client.CompletionStream(context.Background(), "", "Say hello")
+			if err != nil {
+				t.Fatalf("CompletionStream failed: %v", err)
+			}
+			resp, err := stream.Response()
+			if err != nil {
+				t.Fatalf("Response failed: %v", err)
+			}
+			if resp.Content != "Hello, world" {
+				t.Errorf("Expected content 'Hello, world', got '%s'", resp.Content)
+			}
+
+			req := srv.ChatRequests()[0]
+			if got := req.StreamOptions != nil && req.StreamOptions.IncludeUsage; got != tt.usage {
+				t.Errorf("Expected usage requested %v, got stream options %+v", tt.usage, req.StreamOptions)
+			}
+			if got := resp.Usage.TotalTokens == 8; got != tt.usage {
+				t.Errorf("Expected usage reported %v, got %+v", tt.usage, resp.Usage)
+			}
+		})
+	}
+}
+
+func TestStream_ResponseDrainsDeltas(t *testing.T) {
+	srv := openaitest.NewServer(t)
+	srv.Reply(helloReply)
+
+	stream, err := srv.Client().ChatStream(context.Background(), []openaisdk.ChatCompletionMessage{
+		{Role: openaisdk.ChatMessageRoleUser, Content: "Say hello"},
+	})
+	if err != nil {
+		t.Fatalf("ChatStream failed: %v", err)
+	}
+
+	resp, err := stream.Response()
+	if err != nil {
+		t.Fatalf("Response failed: %v", err)
+	}
+
+	if resp.Content != "Hello, world" {
+		t.Errorf("Expected content 'Hello, world', got '%s'", resp.Content)
+	}
+}
+
+func TestStream_BreakCloses(t *testing.T) {
+	srv := openaitest.NewServer(t)
+	srv.Reply(helloReply)
+
+	stream, err := srv.Client().CompletionStream(context.Background(), "", "Say hello")
+	if err != nil {
+		t.Fatalf("CompletionStream failed: %v", err)
+	}
+
+	for range stream.Deltas() {
+		break
+	}
+
+	if _, err := stream.Response(); err == nil {
+		t.Error("Expected error for a stream closed before completion, got nil")
+	}
+}
+
+func TestStream_ContextCanceled(t *testing.T) {
+	srv := openaitest.NewServer(t)
+	srv.Reply(helloReply)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := srv.Client().CompletionStream(ctx, "", "Say hello")
+	if err != nil {
+		t.Fatalf("CompletionStream failed: %v", err)
+	}
+	cancel()
+
+	var gotErr error
+	for _, err := range stream.Deltas() {
+		if err != nil {
+			gotErr = err
+		}
+	}
+
+	if !errors.Is(gotErr, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", gotErr)
+	}
+}
@@ -0,0 +1,149 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+// defaultStructuredAttempts is how many times CompleteInto asks the model
+// before giving up on output that does not match the schema.
+const defaultStructuredAttempts = 3
+
+var schemaNameSanitizer = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// CompleteInto asks the model for a JSON object matching the schema derived from T and
+// decodes it into a T. Providers with strict schema support receive the schema as
+// response_format json_schema; others get json_object mode with the schema in the prompt.
+// When the output does not validate, the model is asked again with the validation error.
+func CompleteInto[T any](ctx context.Context, client *Client, prompt, content string) (T, error) {
+	var out T
+
+	schema, err := jsonschema.GenerateSchemaForType(out)
+	if err != nil {
+		return out, fmt.Errorf("generate schema: %w", err)
+	}
+	if schema.Type != jsonschema.Object {
+		return out, fmt.Errorf("structured output requires a struct type, got %T", out)
+	}
+
+	messages := newCompletionMessages(prompt, content)
+	format := &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject}
+	if client.jsonSchema {
+		format = &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+			JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+				Name:   schemaName(reflect.TypeOf(out)),
+				Schema: schema,
+				Strict: isStrictSchema(*schema),
+			},
+		}
+	} else {
+		raw, err := json.Marshal(schema)
+		if err != nil {
+			return out, fmt.Errorf("encode schema: %w", err)
+		}
+		messages[0].Content += "\n\nRespond only with a JSON object that conforms to this JSON schema:\n" + string(raw)
+	}
+
+	var lastErr error
+	for range defaultStructuredAttempts {
+		req := client.buildChatCompletionRequest(messages)
+		req.ResponseFormat = format
+
+		r, err := client.createChatCompletion(ctx, req)
+		if err != nil {
+			return out, fmt.Errorf("chat completion failed: %w", err)
+		}
+		if len(r.Choices) == 0 {
+			return out, ErrEmptyResponse
+		}
+
+		answer := r.Choices[0].Message.Content
+		var v T
+		if lastErr = schema.Unmarshal(extractJSON(answer), &v); lastErr == nil {
+			return v, nil
+		}
+
+		messages = append(messages,
+			openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: answer},
+			openai.ChatCompletionMessage{
+				Role: openai.ChatMessageRoleUser,
+				Content: fmt.Sprintf("The previous response was not valid: %v. "+
+					"Reply again with only a JSON object that conforms to the schema.", lastErr),
+			},
+		)
+	}
+
+	return out, fmt.Errorf("structured output invalid after %d attempts: %w", defaultStructuredAttempts, lastErr)
+}
+
+// extractJSON strips the Markdown code fence some models wrap around JSON output.
+func extractJSON(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "```") {
+		return s
+	}
+	s = strings.TrimPrefix(s, "```")
+	s = strings.TrimPrefix(s, "json")
+	s = strings.TrimSuffix(strings.TrimSpace(s), "```")
+	return strings.TrimSpace(s)
+}
+
+// schemaName returns a response_format name derived from the Go type.
+func schemaName(t reflect.Type) string {
+	name := schemaNameSanitizer.ReplaceAllString(t.Name(), "_")
+	if name == "" {
+		return "response"
+	}
+	return name
+}
+
+// isStrictSchema reports whether the schema satisfies the strict mode rules:
+// every property is required and no nullable keyword is used.
+func isStrictSchema(d jsonschema.Definition) bool {
+	if d.Nullable || len(d.Required) != len(d.Properties) {
+		return false
+	}
+	for _, p := range d.Properties {
+		if !isStrictSchema(p) {
+			return false
+		}
+	}
+	for _, p := range d.Defs {
+		if !isStrictSchema(p) {
+			return false
+		}
+	}
+	if d.Items != nil {
+		return isStrictSchema(*d.Items)
+	}
+	return true
+}
+
+// supportsJSONSchema reports whether the configured endpoint accepts
+// response_format json_schema. DeepSeek and ZhiPu only offer json_object mode,
+// and the Anthropic Messages API has no response_format at all.
+func supportsJSONSchema(cfg *config) bool {
+	if cfg.provider == Anthropic {
+		return false
+	}
+	model := strings.ToLower(cfg.model)
+	if strings.HasPrefix(model, "deepseek") || strings.HasPrefix(model, "glm") {
+		return false
+	}
+	if u, err := url.Parse(cfg.baseURL); err == nil {
+		host := strings.ToLower(u.Hostname())
+		if strings.Contains(host, "deepseek") || strings.Contains(host, "bigmodel") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,50 @@
+package openai
+
+import (
+	"testing"
+
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+func TestIsStrictSchema(t *testing.T) {
+	type required struct {
+		Label string  `json:"label"`
+		Score float64 `json:"score"`
+	}
+	type optional struct {
+		Name string `json:"name,omitempty"`
+	}
+
+	strict, _ := jsonschema.GenerateSchemaForType(required{})
+	if !isStrictSchema(*strict) {
+		t.Error("Expected schema with only required fields to be strict")
+	}
+
+	loose, _ := jsonschema.GenerateSchemaForType(optional{})
+	if isStrictSchema(*loose) {
+		t.Error("Expected schema with optional fields not to be strict")
+	}
+}
+
+func TestSupportsJSONSchema(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      *config
+		expected bool
+	}{
+		{"OpenAI", &config{model: defaultModel}, true},
+		{"DeepSeek model", &config{model: DeepseekChat}, false},
+		{"ZhiPu model", &config{model: ZhiPuGlmFree}, false},
+		{"DeepSeek endpoint", &config{model: "custom", baseURL: "https://api.deepseek.com/v1"}, false},
+		{"ZhiPu endpoint", &config{model: "custom", baseURL: "https://open.bigmodel.cn/api/paas/v4/"}, false},
+		{"Anthropic", &config{provider: Anthropic, model: "claude-sonnet-4-5"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := supportsJSONSchema(tt.cfg); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package openai_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	openaisdk "github.com/sashabaranov/go-openai"
+
+	"github.com/ysicing/openai/openai"
+	"github.com/ysicing/openai/openai/openaitest"
+)
+
+type sentiment struct {
+	Label string  `json:"label" enum:"positive,negative,neutral"`
+	Score float64 `json:"score"`
+}
+
+func TestCompleteInto_JSONSchema(t *testing.T) {
+	srv := openaitest.NewServer(t)
+	srv.Reply(openaitest.Text(`{"label":"positive","score":0.9}`))
+
+	got, err := openai.CompleteInto[sentiment](context.Background(), srv.Client(), "Classify sentiment.", "I love it")
+	if err != nil {
+		t.Fatalf("CompleteInto failed: %v", err)
+	}
+
+	if got.Label != "positive" || got.Score != 0.9 {
+		t.Errorf("Expected {positive 0.9}, got %+v", got)
+	}
+
+	format := srv.ChatRequests()[0].ResponseFormat
+	if format == nil || format.Type != openaisdk.ChatCompletionResponseFormatTypeJSONSchema {
+		t.Fatalf("Expected json_schema response format, got %+v", format)
+	}
+
+	if format.JSONSchema.Name != "sentiment" || !format.JSONSchema.Strict {
+		t.Errorf("Expected strict schema named 'sentiment', got %+v", format.JSONSchema)
+	}
+}
+
+func TestCompleteInto_JSONObjectFallback(t *testing.T) {
+	srv := openaitest.NewServer(t)
+	srv.Reply(openaitest.Text("```json\n{\"label\":\"neutral\",\"score\":0.5}\n```"))
+
+	client := srv.Client(openai.WithModel(openai.DeepseekChat))
+	got, err := openai.CompleteInto[sentiment](context.Background(), client, "", "It is fine")
+	if err != nil {
+		t.Fatalf("CompleteInto failed: %v", err)
+	}
+
+	if got.Label != "neutral" {
+		t.Errorf("Expected label 'neutral', got '%s'", got.Label)
+	}
+
+	req := srv.ChatRequests()[0]
+	if req.ResponseFormat == nil || req.ResponseFormat.Type != openaisdk.ChatCompletionResponseFormatTypeJSONObject {
+		t.Errorf("Expected json_object response format, got %+v", req.ResponseFormat)
+	}
+
+	if !strings.Contains(req.Messages[0].Content, `"label"`) {
+		t.Error("Expected schema to be included in the system prompt")
+	}
+}
+
+func TestCompleteInto_RetriesInvalidOutput(t *testing.T) {
+	srv := openaitest.NewServer(t)
+	srv.Reply(openaitest.Text(`{"label":"positive"}`), openaitest.Text(`{"label":"negative","score":0.1}`))
+
+	got, err := openai.CompleteInto[sentiment](context.Background(), srv.Client(), "", "Terrible")
+	if err != nil {
+		t.Fatalf("CompleteInto failed: %v", err)
+	}
+
+	if got.Label != "negative" {
+		t.Errorf("Expected label 'negative', got '%s'", got.Label)
+	}
+
+	requests := srv.ChatRequests()
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(requests))
+	}
+
+	retry := requests[1].Messages
+	if !strings.Contains(retry[len(retry)-1].Content, "not valid") {
+		t.Errorf("Expected validation error to be sent back, got '%s'", retry[len(retry)-1].Content)
+	}
+}
+
+func TestCompleteInto_GivesUp(t *testing.T) {
+	srv := openaitest.NewServer(t)
+	srv.OnChat(func(openaisdk.ChatCompletionRequest) openaitest.Reply {
+		return openaitest.Text("not json")
+	})
+
+	if _, err := openai.CompleteInto[sentiment](context.Background(), srv.Client(), "", "?"); err == nil {
+		t.Error("Expected error for invalid output, got nil")
+	}
+
+	if n := len(srv.ChatRequests()); n != openai.DefaultStructuredAttempts {
+		t.Errorf("Expected %d requests, got %d", openai.DefaultStructuredAttempts, n)
+	}
+}
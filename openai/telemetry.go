@@ -0,0 +1,263 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/trace"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+)
+
+const instrumentationName = "github.com/ysicing/openai/openai"
+
+// Attribute keys of the OpenTelemetry GenAI semantic conventions.
+const (
+	attrOperationName    = attribute.Key("gen_ai.operation.name")
+	attrProviderName     = attribute.Key("gen_ai.provider.name")
+	attrRequestModel     = attribute.Key("gen_ai.request.model")
+	attrRequestTemp      = attribute.Key("gen_ai.request.temperature")
+	attrRequestTopP      = attribute.Key("gen_ai.request.top_p")
+	attrRequestPresence  = attribute.Key("gen_ai.request.presence_penalty")
+	attrRequestFrequency = attribute.Key("gen_ai.request.frequency_penalty")
+	attrResponseID       = attribute.Key("gen_ai.response.id")
+	attrResponseModel    = attribute.Key("gen_ai.response.model")
+	attrFinishReasons    = attribute.Key("gen_ai.response.finish_reasons")
+	attrInputTokens      = attribute.Key("gen_ai.usage.input_tokens")
+	attrOutputTokens     = attribute.Key("gen_ai.usage.output_tokens")
+	attrTokenType        = attribute.Key("gen_ai.token.type")
+	attrServerAddress    = attribute.Key("server.address")
+	attrServerPort       = attribute.Key("server.port")
+	attrErrorType        = attribute.Key("error.type")
+)
+
+const (
+	operationChat       = "chat"
+	operationEmbeddings = "embeddings"
+)
+
+// telemetry creates the spans and metrics of API calls. A nil telemetry records nothing.
+type telemetry struct {
+	tracer   trace.Tracer
+	duration metric.Float64Histogram
+	tokens   metric.Int64Histogram
+
+	provider   string
+	serverAddr string
+	serverPort int
+}
+
+// newTelemetry returns the instrumentation configured by WithTracerProvider and
+// WithMeterProvider, or nil when neither is set.
+func newTelemetry(cfg *config) (*telemetry, error) {
+	if cfg.tracerProvider == nil && cfg.meterProvider == nil {
+		return nil, nil
+	}
+	tp := cfg.tracerProvider
+	if tp == nil {
+		tp = tracenoop.NewTracerProvider()
+	}
+	mp := cfg.meterProvider
+	if mp == nil {
+		mp = metricnoop.NewMeterProvider()
+	}
+
+	meter := mp.Meter(instrumentationName)
+	duration, err := meter.Float64Histogram("gen_ai.client.operation.duration",
+		metric.WithDescription("GenAI operation duration"),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(0.01, 0.02, 0.04, 0.08, 0.16, 0.32, 0.64, 1.28, 2.56, 5.12, 10.24, 20.48, 40.96, 81.92),
+	)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := meter.Int64Histogram("gen_ai.client.token.usage",
+		metric.WithDescription("Measures number of input and output tokens used"),
+		metric.WithUnit("{token}"),
+		metric.WithExplicitBucketBoundaries(1, 4, 16, 64, 256, 1024, 4096, 16384, 65536, 262144, 1048576, 4194304, 16777216, 67108864),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &telemetry{
+		tracer:   tp.Tracer(instrumentationName),
+		duration: duration,
+		tokens:   tokens,
+		provider: providerName(cfg),
+	}
+	t.serverAddr, t.serverPort = serverAddress(cfg)
+	return t, nil
+}
+
+// providerName maps the configuration to a well-known gen_ai.provider.name value,
+// falling back to the client name for other OpenAI-compatible services.
+func providerName(cfg *config) string {
+	switch {
+	case cfg.provider == Azure:
+		return "azure.ai.openai"
+	case cfg.provider == Ollama:
+		return Ollama
+	case cfg.provider == Anthropic:
+		return Anthropic
+	case cfg.provider == Gemini:
+		return "gcp.gemini"
+	case strings.Contains(cfg.baseURL, "deepseek"):
+		return "deepseek"
+	case cfg.baseURL == "" || strings.Contains(cfg.baseURL, "api.openai.com"):
+		return "openai"
+	}
+	return clientName(cfg)
+}
+
+func serverAddress(cfg *config) (string, int) {
+	endpoint := cfg.endpoint()
+	if endpoint == "" {
+		return "api.openai.com", 443
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", 0
+	}
+	port, _ := strconv.Atoi(u.Port())
+	if port == 0 && u.Scheme == "https" {
+		port = 443
+	} else if port == 0 && u.Scheme == "http" {
+		port = 80
+	}
+	return u.Hostname(), port
+}
+
+// operation is an API call in flight. A nil operation records nothing.
+type operation struct {
+	t     *telemetry
+	span  trace.Span
+	start time.Time
+	// attrs are shared by the span and the metrics.
+	attrs []attribute.KeyValue
+}
+
+// startChat starts the span of a chat completion request.
+func (t *telemetry) startChat(ctx context.Context, req openai.ChatCompletionRequest) (context.Context, *operation) {
+	if t == nil {
+		return ctx, nil
+	}
+	ctx, op := t.start(ctx, operationChat, req.Model)
+	op.span.SetAttributes(
+		attrRequestTemp.Float64(float64(req.Temperature)),
+		attrRequestTopP.Float64(float64(req.TopP)),
+		attrRequestPresence.Float64(float64(req.PresencePenalty)),
+		attrRequestFrequency.Float64(float64(req.FrequencyPenalty)),
+	)
+	return ctx, op
+}
+
+// startEmbeddings starts the span of an embedding request.
+func (t *telemetry) startEmbeddings(ctx context.Context, model string) (context.Context, *operation) {
+	if t == nil {
+		return ctx, nil
+	}
+	return t.start(ctx, operationEmbeddings, model)
+}
+
+func (t *telemetry) start(ctx context.Context, name, model string) (context.Context, *operation) {
+	attrs := []attribute.KeyValue{
+		attrOperationName.String(name),
+		attrProviderName.String(t.provider),
+		attrRequestModel.String(model),
+	}
+	if t.serverAddr != "" {
+		attrs = append(attrs, attrServerAddress.String(t.serverAddr))
+	}
+	if t.serverPort != 0 {
+		attrs = append(attrs, attrServerPort.Int(t.serverPort))
+	}
+
+	ctx, span := t.tracer.Start(ctx, name+" "+model,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	return ctx, &operation{t: t, span: span, start: time.Now(), attrs: attrs}
+}
+
+// end records the outcome of the call and ends its span.
+func (op *operation) end(ctx context.Context, id, model string, finishReasons []string, usage openai.Usage, err error) {
+	if op == nil {
+		return
+	}
+	attrs := op.attrs
+	if model != "" {
+		attrs = append(attrs, attrResponseModel.String(model))
+	}
+	if err != nil {
+		attrs = append(attrs, attrErrorType.String(errorType(err)))
+		op.span.RecordError(err)
+		op.span.SetStatus(codes.Error, err.Error())
+	}
+	op.span.SetAttributes(attrs[len(op.attrs):]...)
+	if id != "" {
+		op.span.SetAttributes(attrResponseID.String(id))
+	}
+	if len(finishReasons) > 0 {
+		op.span.SetAttributes(attrFinishReasons.StringSlice(finishReasons))
+	}
+	if err == nil {
+		op.span.SetAttributes(
+			attrInputTokens.Int(usage.PromptTokens),
+			attrOutputTokens.Int(usage.CompletionTokens),
+		)
+	}
+	op.span.End()
+
+	set := metric.WithAttributes(attrs...)
+	op.t.duration.Record(ctx, time.Since(op.start).Seconds(), set)
+	if err == nil {
+		op.t.tokens.Record(ctx, int64(usage.PromptTokens),
+			metric.WithAttributes(append(attrs, attrTokenType.String("input"))...))
+		if usage.CompletionTokens > 0 {
+			op.t.tokens.Record(ctx, int64(usage.CompletionTokens),
+				metric.WithAttributes(append(attrs, attrTokenType.String("output"))...))
+		}
+	}
+}
+
+// endChat ends the span of a chat completion request.
+func (op *operation) endChat(ctx context.Context, resp openai.ChatCompletionResponse, err error) {
+	if op == nil {
+		return
+	}
+	reasons := make([]string, 0, len(resp.Choices))
+	for _, choice := range resp.Choices {
+		if choice.FinishReason != "" {
+			reasons = append(reasons, string(choice.FinishReason))
+		}
+	}
+	op.end(ctx, resp.ID, resp.Model, reasons, resp.Usage, err)
+}
+
+// errorType returns a low-cardinality description of err for the error.type attribute.
+func errorType(err error) string {
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) && apiErr.HTTPStatusCode != 0 {
+		return strconv.Itoa(apiErr.HTTPStatusCode)
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) && reqErr.HTTPStatusCode != 0 {
+		return strconv.Itoa(reqErr.HTTPStatusCode)
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	}
+	return "_OTHER"
+}
@@ -0,0 +1,177 @@
+package openai
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newTelemetryClient(t *testing.T, baseURL string) (*Client, *tracetest.InMemoryExporter, *sdkmetric.ManualReader) {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	reader := sdkmetric.NewManualReader()
+
+	client, err := New(
+		WithToken("test-token"),
+		WithBaseURL(baseURL),
+		WithModel("gpt-4o-mini"),
+		WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	return client, exporter, reader
+}
+
+func spanAttrs(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestClient_Telemetry(t *testing.T) {
+	var traceparent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		_, _ = io.WriteString(w, `{"id":"chatcmpl-1","model":"gpt-4o-mini-2024-07-18","choices":[{"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}],"usage":{"prompt_tokens":12,"completion_tokens":3,"total_tokens":15}}`)
+	}))
+	defer srv.Close()
+
+	client, exporter, reader := newTelemetryClient(t, srv.URL)
+	if _, err := client.Completion(context.Background(), "", "hi"); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name != "chat gpt-4o-mini" {
+		t.Errorf("Expected span name 'chat gpt-4o-mini', got '%s'", span.Name)
+	}
+
+	expected := map[attribute.Key]attribute.Value{
+		attrOperationName: attribute.StringValue("chat"),
+		attrProviderName:  attribute.StringValue(client.Name()),
+		attrRequestModel:  attribute.StringValue("gpt-4o-mini"),
+		attrResponseModel: attribute.StringValue("gpt-4o-mini-2024-07-18"),
+		attrResponseID:    attribute.StringValue("chatcmpl-1"),
+		attrInputTokens:   attribute.IntValue(12),
+		attrOutputTokens:  attribute.IntValue(3),
+		attrFinishReasons: attribute.StringSliceValue([]string{"stop"}),
+	}
+	attrs := spanAttrs(span)
+	for key, value := range expected {
+		if attrs[key] != value {
+			t.Errorf("Expected %s=%s, got %s", key, value.Emit(), attrs[key].Emit())
+		}
+	}
+
+	if want := span.SpanContext.TraceID().String(); len(traceparent) < 35 || traceparent[3:35] != want {
+		t.Errorf("Expected traceparent with trace ID %s, got '%s'", want, traceparent)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Failed to collect metrics: %v", err)
+	}
+	counts := make(map[string]uint64)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Histogram[float64]:
+				for _, dp := range data.DataPoints {
+					counts[m.Name] += dp.Count
+				}
+			case metricdata.Histogram[int64]:
+				for _, dp := range data.DataPoints {
+					counts[m.Name] += dp.Count
+				}
+			}
+		}
+	}
+	if counts["gen_ai.client.operation.duration"] != 1 || counts["gen_ai.client.token.usage"] != 2 {
+		t.Errorf("Expected 1 duration and 2 token usage records, got %v", counts)
+	}
+}
+
+func TestClient_TelemetryError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = io.WriteString(w, `{"error":{"message":"boom"}}`)
+	}))
+	defer srv.Close()
+
+	client, exporter, _ := newTelemetryClient(t, srv.URL)
+	if _, err := client.Completion(context.Background(), "", "hi"); err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	if spans[0].Status.Code != codes.Error {
+		t.Errorf("Expected error status, got %v", spans[0].Status)
+	}
+	if got := spanAttrs(spans[0])[attrErrorType].AsString(); got != "500" {
+		t.Errorf("Expected error.type '500', got '%s'", got)
+	}
+}
+
+func TestClient_TelemetryStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, `data: {"id":"1","choices":[{"index":0,"delta":{"content":"Hello"}}]}`+"\n\n")
+		_, _ = io.WriteString(w, `data: {"id":"1","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`+"\n\n")
+		_, _ = io.WriteString(w, `data: {"id":"1","choices":[],"usage":{"prompt_tokens":5,"completion_tokens":3,"total_tokens":8}}`+"\n\n")
+		_, _ = io.WriteString(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	client, exporter, _ := newTelemetryClient(t, srv.URL)
+	stream, err := client.CompletionStream(context.Background(), "", "Say hello")
+	if err != nil {
+		t.Fatalf("CompletionStream failed: %v", err)
+	}
+	defer stream.Close()
+
+	if len(exporter.GetSpans()) != 0 {
+		t.Error("Expected the span to stay open until the stream ends")
+	}
+	if _, err := stream.Response(); err != nil {
+		t.Fatalf("Response failed: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	attrs := spanAttrs(spans[0])
+	if attrs[attrOutputTokens].AsInt64() != 3 || len(attrs[attrFinishReasons].AsStringSlice()) != 1 {
+		t.Errorf("Expected usage and finish reason on the stream span, got %v", spans[0].Attributes)
+	}
+}
+
+func TestNew_WithoutTelemetry(t *testing.T) {
+	client, err := New(WithToken("test-token"))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if client.telemetry != nil {
+		t.Error("Expected no telemetry without tracer or meter provider")
+	}
+}
@@ -0,0 +1,143 @@
+package tokenizer
+
+import (
+	"container/heap"
+	"math"
+	"slices"
+)
+
+// largePiece is the length from which merging switches to a heap, since the
+// linear scan of the reference implementation is quadratic in the piece length.
+const largePiece = 128
+
+// encodePiece appends the tokens of one pre-tokenized piece, merging the
+// lowest-ranked adjacent pair first like the reference tiktoken implementation.
+func (e *Encoding) encodePiece(piece string, tokens []int) []int {
+	if id, ok := e.ranks[piece]; ok {
+		return append(tokens, id)
+	}
+	if len(piece) >= largePiece {
+		return e.encodeLargePiece(piece, tokens)
+	}
+
+	type part struct {
+		start int
+		// rank of the pair starting at this part, math.MaxInt when it cannot merge.
+		rank int
+	}
+	parts := make([]part, len(piece)+1)
+	// pairRank returns the rank of parts i and i+1 merged.
+	pairRank := func(i int) int {
+		if i+2 < len(parts) {
+			if r, ok := e.ranks[piece[parts[i].start:parts[i+2].start]]; ok {
+				return r
+			}
+		}
+		return math.MaxInt
+	}
+	for i := range parts {
+		parts[i].start = i
+	}
+	for i := range parts {
+		parts[i].rank = pairRank(i)
+	}
+
+	for len(parts) > 1 {
+		best := -1
+		for i := range len(parts) - 1 {
+			if parts[i].rank != math.MaxInt && (best < 0 || parts[i].rank < parts[best].rank) {
+				best = i
+			}
+		}
+		if best < 0 {
+			break
+		}
+		parts = slices.Delete(parts, best+1, best+2)
+		parts[best].rank = pairRank(best)
+		if best > 0 {
+			parts[best-1].rank = pairRank(best - 1)
+		}
+	}
+
+	for i := range len(parts) - 1 {
+		tokens = append(tokens, e.ranks[piece[parts[i].start:parts[i+1].start]])
+	}
+	return tokens
+}
+
+// encodeLargePiece produces the same merges as encodePiece using a linked list of
+// parts and a heap of candidate pairs ordered by rank, then position.
+func (e *Encoding) encodeLargePiece(piece string, tokens []int) []int {
+	n := len(piece)
+	// Parts are identified by their start offset; next[n] is the end sentinel.
+	next := make([]int, n+1)
+	prev := make([]int, n+1)
+	version := make([]int, n)
+	alive := make([]bool, n)
+	for i := range n + 1 {
+		next[i] = i + 1
+		prev[i] = i - 1
+	}
+
+	pairs := &pairHeap{}
+	push := func(start int) {
+		version[start]++
+		end := next[start]
+		if end >= n {
+			return
+		}
+		if r, ok := e.ranks[piece[start:next[end]]]; ok {
+			heap.Push(pairs, pair{rank: r, start: start, version: version[start]})
+		}
+	}
+	for i := range n {
+		alive[i] = true
+	}
+	for i := range n {
+		push(i)
+	}
+
+	for pairs.Len() > 0 {
+		p := heap.Pop(pairs).(pair)
+		if !alive[p.start] || p.version != version[p.start] {
+			continue
+		}
+		// Merge the part at p.start with its right neighbour.
+		right := next[p.start]
+		alive[right] = false
+		next[p.start] = next[right]
+		prev[next[right]] = p.start
+		push(p.start)
+		if prev[p.start] >= 0 {
+			push(prev[p.start])
+		}
+	}
+
+	for start := 0; start < n; start = next[start] {
+		tokens = append(tokens, e.ranks[piece[start:next[start]]])
+	}
+	return tokens
+}
+
+// pair is a candidate merge of the part at start with the part after it.
+type pair struct {
+	rank, start, version int
+}
+
+type pairHeap []pair
+
+func (h pairHeap) Len() int { return len(h) }
+func (h pairHeap) Less(i, j int) bool {
+	if h[i].rank != h[j].rank {
+		return h[i].rank < h[j].rank
+	}
+	return h[i].start < h[j].start
+}
+func (h pairHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *pairHeap) Push(x any)   { *h = append(*h, x.(pair)) }
+func (h *pairHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
@@ -0,0 +1,163 @@
+package tokenizer
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Go regexp has no lookahead, so the split patterns of tiktoken are implemented as
+// ordered lists of alternatives evaluated with the same leftmost-first, greedy,
+// backtracking semantics as the reference regular expressions.
+
+// class reports whether a rune belongs to a character class of a split pattern.
+type class func(r rune) bool
+
+// repeat is a character class with a quantifier. max < 0 means unbounded.
+type repeat struct {
+	class    class
+	min, max int
+}
+
+// matcher returns the end of the match of one alternative at position i, or -1.
+type matcher func(rs []rune, i int) int
+
+// pattern is the ordered alternation of a split regular expression.
+type pattern []matcher
+
+var (
+	isLetter = unicode.IsLetter
+	isNumber = unicode.IsNumber
+	isSpace  = unicode.IsSpace
+
+	// [\r\n]
+	isNewline = func(r rune) bool { return r == '\r' || r == '\n' }
+	// [\r\n/]
+	isNewlineOrSlash = func(r rune) bool { return r == '\r' || r == '\n' || r == '/' }
+	// the literal space of " ?"
+	isBlank = func(r rune) bool { return r == ' ' }
+	// [^\r\n\p{L}\p{N}]
+	isLeading = func(r rune) bool { return !isNewline(r) && !isLetter(r) && !isNumber(r) }
+	// [^\s\p{L}\p{N}]
+	isSymbol = func(r rune) bool { return !isSpace(r) && !isLetter(r) && !isNumber(r) }
+	// [\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]
+	isUpper = func(r rune) bool { return unicode.In(r, unicode.Lu, unicode.Lt, unicode.Lm, unicode.Lo, unicode.M) }
+	// [\p{Ll}\p{Lm}\p{Lo}\p{M}]
+	isLower = func(r rune) bool { return unicode.In(r, unicode.Ll, unicode.Lm, unicode.Lo, unicode.M) }
+)
+
+// cl100kPattern implements
+//
+//	(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+(?!\S)|\s+
+var cl100kPattern = pattern{
+	func(rs []rune, i int) int {
+		if n := contraction(rs, i); n > 0 {
+			return i + n
+		}
+		return -1
+	},
+	sequence(nil, repeat{isLeading, 0, 1}, repeat{isLetter, 1, -1}),
+	sequence(nil, repeat{isNumber, 1, 3}),
+	sequence(nil, repeat{isBlank, 0, 1}, repeat{isSymbol, 1, -1}, repeat{isNewline, 0, -1}),
+	sequence(nil, repeat{isSpace, 0, -1}, repeat{isNewline, 1, -1}),
+	trailingSpace,
+	sequence(nil, repeat{isSpace, 1, -1}),
+}
+
+// o200kPattern implements
+//
+//	[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:'s|'t|'re|'ve|'m|'ll|'d)?|
+//	[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|'t|'re|'ve|'m|'ll|'d)?|
+//	\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n/]*|\s*[\r\n]+|\s+(?!\S)|\s+
+var o200kPattern = pattern{
+	sequence(contraction, repeat{isLeading, 0, 1}, repeat{isUpper, 0, -1}, repeat{isLower, 1, -1}),
+	sequence(contraction, repeat{isLeading, 0, 1}, repeat{isUpper, 1, -1}, repeat{isLower, 0, -1}),
+	sequence(nil, repeat{isNumber, 1, 3}),
+	sequence(nil, repeat{isBlank, 0, 1}, repeat{isSymbol, 1, -1}, repeat{isNewlineOrSlash, 0, -1}),
+	sequence(nil, repeat{isSpace, 0, -1}, repeat{isNewline, 1, -1}),
+	trailingSpace,
+	sequence(nil, repeat{isSpace, 1, -1}),
+}
+
+// sequence matches the repeats in order, followed by an optional suffix
+// that returns the length it matched.
+func sequence(suffix func(rs []rune, i int) int, items ...repeat) matcher {
+	var match func(rs []rune, i, k int) int
+	match = func(rs []rune, i, k int) int {
+		if k == len(items) {
+			if suffix != nil {
+				return i + suffix(rs, i)
+			}
+			return i
+		}
+		it := items[k]
+		n := 0
+		for i+n < len(rs) && (it.max < 0 || n < it.max) && it.class(rs[i+n]) {
+			n++
+		}
+		// Greedy: try the longest run first and give back one rune at a time.
+		for c := n; c >= it.min; c-- {
+			if end := match(rs, i+c, k+1); end >= 0 {
+				return end
+			}
+		}
+		return -1
+	}
+	return func(rs []rune, i int) int {
+		return match(rs, i, 0)
+	}
+}
+
+// trailingSpace implements \s+(?!\S): a whitespace run that is not followed by a
+// non-space, which leaves the last space of a run to prefix the next word.
+func trailingSpace(rs []rune, i int) int {
+	j := i
+	for j < len(rs) && isSpace(rs[j]) {
+		j++
+	}
+	switch {
+	case j == i:
+		return -1
+	case j == len(rs):
+		return j
+	case j-1 > i:
+		return j - 1
+	}
+	return -1
+}
+
+// contraction implements (?i:'s|'t|'re|'ve|'m|'ll|'d) and returns the matched length.
+func contraction(rs []rune, i int) int {
+	if i+1 >= len(rs) || rs[i] != '\'' {
+		return 0
+	}
+	for _, suffix := range []string{"s", "t", "re", "ve", "m", "ll", "d"} {
+		n := len(suffix)
+		if i+1+n <= len(rs) && strings.EqualFold(string(rs[i+1:i+1+n]), suffix) {
+			return n + 1
+		}
+	}
+	return 0
+}
+
+// split cuts text into the pieces that are encoded independently.
+func (p pattern) split(text string, fn func(piece string)) {
+	rs := make([]rune, 0, len(text))
+	offsets := make([]int, 0, len(text)+1)
+	for off, r := range text {
+		rs = append(rs, r)
+		offsets = append(offsets, off)
+	}
+	offsets = append(offsets, len(text))
+
+	for i := 0; i < len(rs); {
+		end := i + 1
+		for _, m := range p {
+			if e := m(rs, i); e > i {
+				end = e
+				break
+			}
+		}
+		fn(text[offsets[i]:offsets[end]])
+		i = end
+	}
+}
@@ -0,0 +1,200 @@
+// Package tokenizer counts tokens the way OpenAI models see text.
+//
+// The cl100k_base and o200k_base byte pair encodings are implemented natively with
+// their vocabularies embedded, so counting works offline. Models whose tokenizer is
+// not bundled, such as DeepSeek and GLM, are estimated from character classes.
+package tokenizer
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"embed"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+//go:embed data/*.tiktoken.gz
+var vocabularies embed.FS
+
+// Counter counts the tokens of a text.
+type Counter interface {
+	Count(text string) int
+}
+
+// Encoding is a byte pair encoding. Its vocabulary is loaded on first use.
+type Encoding struct {
+	name    string
+	pattern pattern
+
+	once   sync.Once
+	ranks  map[string]int
+	tokens [][]byte
+}
+
+var (
+	// Cl100kBase is the encoding of GPT-4, GPT-3.5 and the text-embedding-3 models.
+	Cl100kBase = &Encoding{name: "cl100k_base", pattern: cl100kPattern}
+	// O200kBase is the encoding of GPT-4o, GPT-4.1, GPT-5 and the o-series models.
+	O200kBase = &Encoding{name: "o200k_base", pattern: o200kPattern}
+)
+
+// Get returns the encoding with the given tiktoken name.
+func Get(name string) (*Encoding, error) {
+	switch name {
+	case Cl100kBase.name:
+		return Cl100kBase, nil
+	case O200kBase.name:
+		return O200kBase, nil
+	}
+	return nil, fmt.Errorf("unknown encoding %q", name)
+}
+
+// Name returns the tiktoken name of the encoding.
+func (e *Encoding) Name() string {
+	return e.name
+}
+
+// Encode returns the tokens of text. Special tokens such as <|endoftext|>
+// are encoded as ordinary text.
+func (e *Encoding) Encode(text string) []int {
+	e.load()
+	var tokens []int
+	e.pattern.split(text, func(piece string) {
+		tokens = e.encodePiece(piece, tokens)
+	})
+	return tokens
+}
+
+// Count returns the number of tokens of text.
+func (e *Encoding) Count(text string) int {
+	return len(e.Encode(text))
+}
+
+// Decode returns the text of the tokens. Unknown tokens are skipped.
+func (e *Encoding) Decode(tokens []int) string {
+	e.load()
+	var b strings.Builder
+	for _, t := range tokens {
+		if t >= 0 && t < len(e.tokens) {
+			b.Write(e.tokens[t])
+		}
+	}
+	return b.String()
+}
+
+// load parses the embedded vocabulary, a gzipped tiktoken file of
+// "base64(token) rank" lines. The data ships with the package, so a failure is a bug.
+func (e *Encoding) load() {
+	e.once.Do(func() {
+		f, err := vocabularies.Open("data/" + e.name + ".tiktoken.gz")
+		if err != nil {
+			panic(fmt.Sprintf("tokenizer: %s: %v", e.name, err))
+		}
+		defer f.Close()
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			panic(fmt.Sprintf("tokenizer: %s: %v", e.name, err))
+		}
+
+		e.ranks = make(map[string]int, 200000)
+		scanner := bufio.NewScanner(zr)
+		for scanner.Scan() {
+			token, rank, ok := bytes.Cut(scanner.Bytes(), []byte{' '})
+			if !ok {
+				continue
+			}
+			raw, err := base64.StdEncoding.DecodeString(string(token))
+			if err != nil {
+				panic(fmt.Sprintf("tokenizer: %s: %v", e.name, err))
+			}
+			id, err := strconv.Atoi(string(rank))
+			if err != nil {
+				panic(fmt.Sprintf("tokenizer: %s: %v", e.name, err))
+			}
+			e.ranks[string(raw)] = id
+			if id >= len(e.tokens) {
+				e.tokens = append(e.tokens, make([][]byte, id-len(e.tokens)+1)...)
+			}
+			e.tokens[id] = raw
+		}
+		if err := scanner.Err(); err != nil {
+			panic(fmt.Sprintf("tokenizer: %s: %v", e.name, err))
+		}
+	})
+}
+
+// Approximation estimates token counts from character classes, for models whose
+// tokenizer is not bundled. It is meant for budget checks, not exact accounting.
+type Approximation struct {
+	// CJK is the number of tokens per Han, Kana or Hangul character.
+	CJK float64
+	// Other is the number of tokens per any other character.
+	Other float64
+}
+
+var (
+	// DeepSeek follows the DeepSeek documentation: about 0.3 tokens per English
+	// character and 0.6 tokens per Chinese character.
+	DeepSeek = Approximation{CJK: 0.6, Other: 0.3}
+	// GLM follows the ZhiPu documentation: about 1.6 Chinese characters per token,
+	// with English close to the OpenAI ratio of 4 characters per token.
+	GLM = Approximation{CJK: 0.625, Other: 0.25}
+)
+
+// Count implements the Counter interface.
+func (a Approximation) Count(text string) int {
+	var n float64
+	for _, r := range text {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+			n += a.CJK
+		} else {
+			n += a.Other
+		}
+	}
+	return int(math.Ceil(n))
+}
+
+// modelPrefixes maps model name prefixes to their counter, most specific first.
+var modelPrefixes = []struct {
+	prefix  string
+	counter Counter
+}{
+	{"gpt-4o", O200kBase},
+	{"chatgpt-4o", O200kBase},
+	{"gpt-4.1", O200kBase},
+	{"gpt-4.5", O200kBase},
+	{"gpt-5", O200kBase},
+	{"gpt-oss", O200kBase},
+	{"o1", O200kBase},
+	{"o3", O200kBase},
+	{"o4", O200kBase},
+	{"gpt-4", Cl100kBase},
+	{"gpt-3.5", Cl100kBase},
+	{"gpt-35", Cl100kBase},
+	{"text-embedding-3", Cl100kBase},
+	{"text-embedding-ada-002", Cl100kBase},
+	{"deepseek", DeepSeek},
+	{"glm", GLM},
+}
+
+// ForModel returns the counter matching the model. Unknown models, such as local
+// models served by Ollama, are counted with cl100k_base as a close estimate.
+func ForModel(model string) Counter {
+	model = strings.ToLower(model)
+	// Drop a vendor prefix such as "openai/gpt-4o".
+	if i := strings.LastIndexByte(model, '/'); i >= 0 {
+		model = model[i+1:]
+	}
+	for _, m := range modelPrefixes {
+		if strings.HasPrefix(model, m.prefix) {
+			return m.counter
+		}
+	}
+	return Cl100kBase
+}
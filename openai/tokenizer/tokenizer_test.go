@@ -0,0 +1,115 @@
+package tokenizer
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestEncoding_Encode(t *testing.T) {
+	// Reference tokens produced by tiktoken.
+	tests := []struct {
+		encoding *Encoding
+		text     string
+		expected []int
+	}{
+		{Cl100kBase, "hello world", []int{15339, 1917}},
+		{Cl100kBase, "I'm here, you're THERE", []int{40, 2846, 1618, 11, 499, 2351, 62207}},
+		{Cl100kBase, "你好，世界！", []int{57668, 53901, 3922, 3574, 244, 98220, 6447}},
+		{Cl100kBase, "  indented\n\n\tcode()  \n", []int{220, 1280, 16243, 271, 44443, 368, 2355}},
+		{Cl100kBase, "HTTPServer 12345", []int{9412, 5592, 220, 4513, 1774}},
+		{Cl100kBase, "path/to/file.go\r\n", []int{2398, 33529, 24849, 18487, 319}},
+		{O200kBase, "hello world", []int{24912, 2375}},
+		{O200kBase, "I'm here, you're THERE", []int{15390, 2105, 11, 7163, 102774}},
+		{O200kBase, "你好，世界！", []int{177519, 979, 28428, 3393}},
+		{O200kBase, "  indented\n\n\tcode()  \n", []int{220, 1383, 23537, 279, 86873, 416, 4066}},
+		{O200kBase, "HTTPServer 12345", []int{17893, 6444, 220, 7633, 2548}},
+		{O200kBase, "path/to/file.go\r\n", []int{4189, 72231, 51766, 32812, 370}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.encoding.Name()+"/"+tt.text, func(t *testing.T) {
+			got := tt.encoding.Encode(tt.text)
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("Expected tokens %v, got %v", tt.expected, got)
+			}
+
+			if decoded := tt.encoding.Decode(got); decoded != tt.text {
+				t.Errorf("Expected decoded text %q, got %q", tt.text, decoded)
+			}
+		})
+	}
+}
+
+func TestEncoding_LargePiece(t *testing.T) {
+	// Pieces above largePiece use the heap merge, which must agree with the linear merge.
+	piece := strings.Repeat("abcxyz", largePiece)
+	for _, enc := range []*Encoding{Cl100kBase, O200kBase} {
+		enc.load()
+		small := enc.encodePiece(piece[:largePiece-1], nil)
+		if got := enc.encodeLargePiece(piece[:largePiece-1], nil); !slices.Equal(got, small) {
+			t.Errorf("%s: expected heap merge %v to equal linear merge %v", enc.Name(), got, small)
+		}
+
+		if decoded := enc.Decode(enc.Encode(piece)); decoded != piece {
+			t.Errorf("%s: expected large piece to round-trip", enc.Name())
+		}
+	}
+}
+
+func TestGet(t *testing.T) {
+	if enc, err := Get("o200k_base"); err != nil || enc != O200kBase {
+		t.Errorf("Expected o200k_base, got (%v, %v)", enc, err)
+	}
+
+	if _, err := Get("p50k_base"); err == nil {
+		t.Error("Expected error for unknown encoding, got nil")
+	}
+}
+
+func TestForModel(t *testing.T) {
+	tests := []struct {
+		model    string
+		expected Counter
+	}{
+		{"gpt-4o-mini", O200kBase},
+		{"gpt-4.1", O200kBase},
+		{"o3-mini", O200kBase},
+		{"openai/gpt-5", O200kBase},
+		{"gpt-4-turbo", Cl100kBase},
+		{"gpt-3.5-turbo", Cl100kBase},
+		{"deepseek-chat", DeepSeek},
+		{"glm-4-flash", GLM},
+		{"llama3.2", Cl100kBase},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			if got := ForModel(tt.model); got != tt.expected {
+				t.Errorf("Expected counter %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestApproximation_Count(t *testing.T) {
+	tests := []struct {
+		name     string
+		counter  Approximation
+		text     string
+		expected int
+	}{
+		{"DeepSeek English", DeepSeek, "hello world", 4},
+		{"DeepSeek Chinese", DeepSeek, "你好世界", 3},
+		{"GLM mixed", GLM, "GLM 你好", 3},
+		{"Empty", GLM, "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.counter.Count(tt.text); got != tt.expected {
+				t.Errorf("Expected %d tokens, got %d", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package openai
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"image"
+	_ "image/gif"  // register decoders for image size detection
+	_ "image/jpeg" // register decoders for image size detection
+	_ "image/png"  // register decoders for image size detection
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+
+	"github.com/ysicing/openai/openai/tokenizer"
+)
+
+const (
+	// Every chat message is framed by a few tokens, a name costs one more,
+	// and the reply is primed with a few more.
+	tokensPerMessage = 3
+	tokensPerName    = 1
+	tokensPerReply   = 3
+
+	// Images are billed by 512px tile on top of a base cost. Low detail images cost
+	// the base only; without the dimensions assume a high detail 1024x1024 image.
+	imageBaseTokens    = 85
+	imageTileTokens    = 170
+	defaultImageTokens = imageBaseTokens + 4*imageTileTokens
+)
+
+// CountTokens returns the number of prompt tokens the messages take for the model
+// of the client, including the chat format overhead and image parts. It is exact for
+// OpenAI models and an estimate for others, see tokenizer.ForModel.
+func (c *Client) CountTokens(messages []openai.ChatCompletionMessage) int {
+	return countTokens(c.counter(), openai.ChatCompletionRequest{Messages: messages})
+}
+
+// counter returns the tokenizer of the client model.
+func (c *Client) counter() tokenizer.Counter {
+	return tokenizer.ForModel(c.model)
+}
+
+// reserve waits until the rate limiter admits the request. Requests are only
+// tokenized when a limiter is configured.
+func (c *Client) reserve(ctx context.Context, req openai.ChatCompletionRequest) (*reservation, error) {
+	if c.limiter == nil {
+		return nil, nil
+	}
+	return c.limiter.wait(ctx, countTokens(c.counter(), req))
+}
+
+// countTokens returns the prompt size of a chat completion request.
+func countTokens(counter tokenizer.Counter, req openai.ChatCompletionRequest) int {
+	n := tokensPerReply
+	for _, m := range req.Messages {
+		n += tokensPerMessage + counter.Count(m.Role) + counter.Count(m.Content)
+		if m.Name != "" {
+			n += tokensPerName + counter.Count(m.Name)
+		}
+		for _, part := range m.MultiContent {
+			switch part.Type {
+			case openai.ChatMessagePartTypeImageURL:
+				n += imageTokens(part.ImageURL)
+			default:
+				n += counter.Count(part.Text)
+			}
+		}
+		for _, call := range m.ToolCalls {
+			n += counter.Count(call.Function.Name) + counter.Count(call.Function.Arguments)
+		}
+	}
+	// Tool definitions are injected into the prompt; their JSON is a close estimate.
+	if len(req.Tools) > 0 {
+		if defs, err := json.Marshal(req.Tools); err == nil {
+			n += counter.Count(string(defs))
+		}
+	}
+	return n
+}
+
+// imageTokens returns the cost of an image part, reading the dimensions of data URLs.
+func imageTokens(img *openai.ChatMessageImageURL) int {
+	if img == nil {
+		return 0
+	}
+	if img.Detail == openai.ImageURLDetailLow {
+		return imageBaseTokens
+	}
+	width, height, ok := imageSize(img.URL)
+	if !ok {
+		return defaultImageTokens
+	}
+	return imageTokensForSize(width, height)
+}
+
+// imageTokensForSize follows the OpenAI vision pricing: the image is scaled to fit
+// in 2048x2048, then down to 768px on its shortest side, and billed per 512px tile.
+func imageTokensForSize(width, height int) int {
+	if width <= 0 || height <= 0 {
+		return defaultImageTokens
+	}
+	if longest := max(width, height); longest > 2048 {
+		width, height = width*2048/longest, height*2048/longest
+	}
+	if shortest := min(width, height); shortest > 768 {
+		width, height = width*768/shortest, height*768/shortest
+	}
+	tiles := ((width + 511) / 512) * ((height + 511) / 512)
+	return imageBaseTokens + tiles*imageTileTokens
+}
+
+// imageSize decodes the dimensions of a base64 data URL. Remote images are not fetched.
+func imageSize(url string) (int, int, bool) {
+	if !strings.HasPrefix(url, "data:") {
+		return 0, 0, false
+	}
+	_, data, ok := strings.Cut(url, ";base64,")
+	if !ok {
+		return 0, 0, false
+	}
+	cfg, _, err := image.DecodeConfig(base64.NewDecoder(base64.StdEncoding, strings.NewReader(data)))
+	if err != nil {
+		return 0, 0, false
+	}
+	return cfg.Width, cfg.Height, true
+}
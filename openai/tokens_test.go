@@ -0,0 +1,101 @@
+package openai
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"testing"
+
+	openaisdk "github.com/sashabaranov/go-openai"
+)
+
+func TestClient_CountTokens(t *testing.T) {
+	tests := []struct {
+		name     string
+		model    string
+		messages []openaisdk.ChatCompletionMessage
+		expected int
+	}{
+		// reply priming + message frame + "user" + "hello world"
+		{"Single message", "gpt-4o", []openaisdk.ChatCompletionMessage{{Role: "user", Content: "hello world"}}, 3 + 3 + 1 + 2},
+		// the name adds its own tokens plus one
+		{"Named message", "gpt-4", []openaisdk.ChatCompletionMessage{{Role: "user", Name: "alice", Content: "hello world"}}, 3 + 3 + 1 + 2 + 1 + 1},
+		{"Multiple messages", "gpt-4o-mini", []openaisdk.ChatCompletionMessage{
+			{Role: "system", Content: "hello world"},
+			{Role: "user", Content: "hello world"},
+		}, 3 + 2*(3+1+2)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := New(WithToken("test-token"), WithModel(tt.model))
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+
+			if got := client.CountTokens(tt.messages); got != tt.expected {
+				t.Errorf("Expected %d tokens, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestClient_CountTokensApproximate(t *testing.T) {
+	client, err := New(WithToken("test-token"), WithModel(DeepseekChat))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	// reply priming + message frame + role "user" (4 * 0.3) + content (4 * 0.6)
+	got := client.CountTokens([]openaisdk.ChatCompletionMessage{{Role: "user", Content: "你好世界"}})
+	if expected := tokensPerReply + tokensPerMessage + 2 + 3; got != expected {
+		t.Errorf("Expected %d tokens, got %d", expected, got)
+	}
+}
+
+func TestImageTokens(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1024, 2048))); err != nil {
+		t.Fatalf("Failed to encode image: %v", err)
+	}
+	dataURL := "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	tests := []struct {
+		name     string
+		img      *openaisdk.ChatMessageImageURL
+		expected int
+	}{
+		{"Low detail", &openaisdk.ChatMessageImageURL{URL: dataURL, Detail: openaisdk.ImageURLDetailLow}, 85},
+		// 1024x2048 is scaled to 768x1536: 2x3 tiles.
+		{"Data URL", &openaisdk.ChatMessageImageURL{URL: dataURL}, 85 + 6*170},
+		{"Remote URL", &openaisdk.ChatMessageImageURL{URL: "https://example.com/a.png"}, defaultImageTokens},
+		{"Nil", nil, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := imageTokens(tt.img); got != tt.expected {
+				t.Errorf("Expected %d tokens, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestImageTokensForSize(t *testing.T) {
+	// Examples of the OpenAI vision pricing documentation.
+	tests := []struct {
+		width, height int
+		expected      int
+	}{
+		{1024, 1024, 765},
+		{2048, 4096, 1105},
+		{512, 512, 255},
+	}
+
+	for _, tt := range tests {
+		if got := imageTokensForSize(tt.width, tt.height); got != tt.expected {
+			t.Errorf("%dx%d: expected %d tokens, got %d", tt.width, tt.height, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	openai "github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+const defaultMaxToolIterations = 8
+
+var errMaxToolIterations = errors.New("tool loop did not produce a final answer")
+
+// ToolHandler executes a tool call. It receives the raw JSON arguments chosen by the model
+// and returns the result that is sent back to the model.
+type ToolHandler func(ctx context.Context, arguments string) (string, error)
+
+// Tool is a function the model may call during RunWithTools.
+type Tool struct {
+	Name        string
+	Description string
+	// Parameters is the JSON schema of the arguments object.
+	Parameters any
+	Handler    ToolHandler
+}
+
+// NewTool creates a Tool whose JSON schema is derived from the argument struct T.
+// Field names follow the json tags of T; the `description` and `enum` tags are
+// copied into the schema and fields tagged omitempty are optional.
+func NewTool[T any](
+	name, description string,
+	fn func(ctx context.Context, args T) (string, error),
+) (Tool, error) {
+	var zero T
+	schema, err := jsonschema.GenerateSchemaForType(zero)
+	if err != nil {
+		return Tool{}, fmt.Errorf("tool %q: %w", name, err)
+	}
+
+	return Tool{
+		Name:        name,
+		Description: description,
+		Parameters:  schema,
+		Handler: func(ctx context.Context, arguments string) (string, error) {
+			var args T
+			if arguments == "" {
+				arguments = "{}"
+			}
+			if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+				return "", fmt.Errorf("invalid arguments: %w", err)
+			}
+			return fn(ctx, args)
+		},
+	}, nil
+}
+
+// toolRegistry holds the tools available to a Client in registration order.
+type toolRegistry struct {
+	mu    sync.RWMutex
+	tools []Tool
+}
+
+func (r *toolRegistry) register(tools ...Tool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, t := range tools {
+		if t.Name == "" || t.Handler == nil {
+			return errors.New("tool requires a name and a handler")
+		}
+		// Registering a tool with an existing name replaces it.
+		if i := slices.IndexFunc(r.tools, func(e Tool) bool { return e.Name == t.Name }); i >= 0 {
+			r.tools[i] = t
+			continue
+		}
+		r.tools = append(r.tools, t)
+	}
+	return nil
+}
+
+// definitions returns the tool list in the form expected by the chat API.
+func (r *toolRegistry) definitions() []openai.Tool {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.tools) == 0 {
+		return nil
+	}
+	defs := make([]openai.Tool, 0, len(r.tools))
+	for _, t := range r.tools {
+		defs = append(defs, openai.Tool{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        t.Name,
+				Description: t.Description,
+				Parameters:  t.Parameters,
+			},
+		})
+	}
+	return defs
+}
+
+// call dispatches a tool call to its handler. Failures are reported back to the
+// model as the tool result so it can correct itself instead of aborting the loop.
+func (r *toolRegistry) call(ctx context.Context, call openai.ToolCall) string {
+	r.mu.RLock()
+	i := slices.IndexFunc(r.tools, func(e Tool) bool { return e.Name == call.Function.Name })
+	var handler ToolHandler
+	if i >= 0 {
+		handler = r.tools[i].Handler
+	}
+	r.mu.RUnlock()
+
+	if handler == nil {
+		return fmt.Sprintf("error: unknown tool %q", call.Function.Name)
+	}
+	result, err := handler(ctx, call.Function.Arguments)
+	if err != nil {
+		return "error: " + err.Error()
+	}
+	return result
+}
+
+// RegisterTool makes the given tools available to the model in RunWithTools and Conversation.Send.
+func (c *Client) RegisterTool(tools ...Tool) error {
+	return c.tools.register(tools...)
+}
+
+// RunWithTools sends the conversation and executes the tool calls requested by the model,
+// feeding their results back until the model produces a final answer or the iteration
+// limit set by WithMaxToolIterations is reached.
+// It returns the final response together with the extended conversation.
+func (c *Client) RunWithTools(
+	ctx context.Context,
+	messages []openai.ChatCompletionMessage,
+) (*Response, []openai.ChatCompletionMessage, error) {
+	messages = slices.Clone(messages)
+	resp := &Response{Provider: c.name}
+
+	for range c.maxToolIterations {
+		req := c.buildChatCompletionRequest(messages)
+		req.Tools = c.tools.definitions()
+		r, err := c.createChatCompletion(ctx, req)
+		if err != nil {
+			return nil, messages, fmt.Errorf("chat completion failed: %w", err)
+		}
+		if len(r.Choices) == 0 {
+			return nil, messages, ErrEmptyResponse
+		}
+
+		msg := r.Choices[0].Message
+		messages = append(messages, msg)
+		addUsage(&resp.Usage, r.Usage)
+
+		if len(msg.ToolCalls) == 0 {
+			resp.Content = msg.Content
+			resp.FinishReason = r.Choices[0].FinishReason
+			resp.CacheHit = IsCacheHit(r)
+			return resp, messages, nil
+		}
+
+		for _, call := range msg.ToolCalls {
+			messages = append(messages, openai.ChatCompletionMessage{
+				Role:       openai.ChatMessageRoleTool,
+				Content:    c.tools.call(ctx, call),
+				Name:       call.Function.Name,
+				ToolCallID: call.ID,
+			})
+		}
+	}
+
+	return nil, messages, fmt.Errorf("%w after %d iterations", errMaxToolIterations, c.maxToolIterations)
+}
+
+// addUsage accumulates token counts across several requests.
+func addUsage(total *openai.Usage, u openai.Usage) {
+	total.PromptTokens += u.PromptTokens
+	total.CompletionTokens += u.CompletionTokens
+	total.TotalTokens += u.TotalTokens
+}
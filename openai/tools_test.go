@@ -0,0 +1,241 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	openaisdk "github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+type weatherArgs struct {
+	City string `json:"city" description:"Name of the city"`
+	Unit string `json:"unit,omitempty" enum:"celsius,fahrenheit"`
+}
+
+func newWeatherTool(t *testing.T) Tool {
+	t.Helper()
+	tool, err := NewTool("get_weather", "Get the current weather",
+		func(_ context.Context, args weatherArgs) (string, error) {
+			if args.City == "" {
+				return "", errors.New("city is required")
+			}
+			return "sunny in " + args.City, nil
+		})
+	if err != nil {
+		t.Fatalf("NewTool failed: %v", err)
+	}
+	return tool
+}
+
+func TestNewTool_Schema(t *testing.T) {
+	tool := newWeatherTool(t)
+
+	schema, ok := tool.Parameters.(*jsonschema.Definition)
+	if !ok {
+		t.Fatalf("Expected *jsonschema.Definition, got %T", tool.Parameters)
+	}
+
+	if schema.Type != jsonschema.Object {
+		t.Errorf("Expected object schema, got '%s'", schema.Type)
+	}
+
+	if schema.Properties["city"].Description != "Name of the city" {
+		t.Errorf("Expected city description, got '%s'", schema.Properties["city"].Description)
+	}
+
+	if len(schema.Required) != 1 || schema.Required[0] != "city" {
+		t.Errorf("Expected only 'city' to be required, got %v", schema.Required)
+	}
+}
+
+func TestNewTool_Handler(t *testing.T) {
+	tool := newWeatherTool(t)
+
+	result, err := tool.Handler(context.Background(), `{"city":"Paris"}`)
+	if err != nil {
+		t.Fatalf("Handler failed: %v", err)
+	}
+
+	if result != "sunny in Paris" {
+		t.Errorf("Expected 'sunny in Paris', got '%s'", result)
+	}
+
+	if _, err := tool.Handler(context.Background(), `not json`); err == nil {
+		t.Error("Expected error for invalid arguments, got nil")
+	}
+}
+
+func TestToolRegistry_Register(t *testing.T) {
+	r := &toolRegistry{}
+
+	if err := r.register(Tool{Name: "missing-handler"}); err == nil {
+		t.Error("Expected error for tool without handler, got nil")
+	}
+
+	handler := func(context.Context, string) (string, error) { return "", nil }
+	if err := r.register(Tool{Name: "a", Handler: handler}, Tool{Name: "a", Description: "replaced", Handler: handler}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	defs := r.definitions()
+	if len(defs) != 1 {
+		t.Fatalf("Expected 1 tool definition, got %d", len(defs))
+	}
+
+	if defs[0].Function.Description != "replaced" {
+		t.Errorf("Expected re-registered tool to replace the old one, got '%s'", defs[0].Function.Description)
+	}
+}
+
+func toolCallResponse(id, name, args string) openaisdk.ChatCompletionResponse {
+	return openaisdk.ChatCompletionResponse{
+		Choices: []openaisdk.ChatCompletionChoice{{
+			Message: openaisdk.ChatCompletionMessage{
+				Role: openaisdk.ChatMessageRoleAssistant,
+				ToolCalls: []openaisdk.ToolCall{{
+					ID:       id,
+					Type:     openaisdk.ToolTypeFunction,
+					Function: openaisdk.FunctionCall{Name: name, Arguments: args},
+				}},
+			},
+			FinishReason: openaisdk.FinishReasonToolCalls,
+		}},
+		Usage: openaisdk.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+	}
+}
+
+func TestClient_RunWithTools(t *testing.T) {
+	var requests []openaisdk.ChatCompletionRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openaisdk.ChatCompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		requests = append(requests, req)
+
+		resp := toolCallResponse("call_1", "get_weather", `{"city":"Paris"}`)
+		if len(requests) > 1 {
+			resp = openaisdk.ChatCompletionResponse{
+				Choices: []openaisdk.ChatCompletionChoice{{
+					Message:      openaisdk.ChatCompletionMessage{Role: openaisdk.ChatMessageRoleAssistant, Content: "It is sunny."},
+					FinishReason: openaisdk.FinishReasonStop,
+				}},
+				Usage: openaisdk.Usage{PromptTokens: 20, CompletionTokens: 4, TotalTokens: 24},
+			}
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	client, err := New(
+		WithToken("test-token"),
+		WithBaseURL(srv.URL),
+		WithTools(newWeatherTool(t)),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, history, err := client.RunWithTools(context.Background(), []openaisdk.ChatCompletionMessage{
+		{Role: openaisdk.ChatMessageRoleUser, Content: "Weather in Paris?"},
+	})
+	if err != nil {
+		t.Fatalf("RunWithTools failed: %v", err)
+	}
+
+	if resp.Content != "It is sunny." {
+		t.Errorf("Expected final answer 'It is sunny.', got '%s'", resp.Content)
+	}
+
+	if resp.Usage.TotalTokens != 39 {
+		t.Errorf("Expected aggregated usage of 39 tokens, got %d", resp.Usage.TotalTokens)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(requests))
+	}
+
+	if len(requests[0].Tools) != 1 || requests[0].Tools[0].Function.Name != "get_weather" {
+		t.Errorf("Expected get_weather tool to be sent, got %+v", requests[0].Tools)
+	}
+
+	toolMsg := requests[1].Messages[len(requests[1].Messages)-1]
+	if toolMsg.Role != openaisdk.ChatMessageRoleTool || toolMsg.ToolCallID != "call_1" {
+		t.Errorf("Expected tool result for call_1, got %+v", toolMsg)
+	}
+
+	if toolMsg.Content != "sunny in Paris" {
+		t.Errorf("Expected tool result 'sunny in Paris', got '%s'", toolMsg.Content)
+	}
+
+	// user, assistant tool call, tool result, final answer
+	if len(history) != 4 {
+		t.Errorf("Expected 4 messages in history, got %d", len(history))
+	}
+}
+
+func TestClient_RunWithTools_MaxIterations(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		_ = json.NewEncoder(w).Encode(toolCallResponse("call", "unknown_tool", `{}`))
+	}))
+	defer srv.Close()
+
+	client, err := New(
+		WithToken("test-token"),
+		WithBaseURL(srv.URL),
+		WithMaxToolIterations(3),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	_, history, err := client.RunWithTools(context.Background(), []openaisdk.ChatCompletionMessage{
+		{Role: openaisdk.ChatMessageRoleUser, Content: "Loop forever"},
+	})
+	if !errors.Is(err, errMaxToolIterations) {
+		t.Errorf("Expected errMaxToolIterations, got %v", err)
+	}
+
+	if calls != 3 {
+		t.Errorf("Expected 3 requests, got %d", calls)
+	}
+
+	if !strings.Contains(history[2].Content, "unknown tool") {
+		t.Errorf("Expected unknown tool error to be reported to the model, got '%s'", history[2].Content)
+	}
+}
+
+func TestClient_CompletionWithoutTools(t *testing.T) {
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"Hello"},"finish_reason":"stop"}]}`))
+	}))
+	defer srv.Close()
+
+	client, err := New(WithToken("test-token"), WithBaseURL(srv.URL), WithTools(newWeatherTool(t)))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Completion(context.Background(), "", "Weather in Paris?")
+	if err != nil {
+		t.Fatalf("Completion failed: %v", err)
+	}
+	if resp.Content != "Hello" {
+		t.Errorf("Expected 'Hello', got '%s'", resp.Content)
+	}
+	if _, ok := body["tools"]; ok {
+		t.Errorf("Expected no tools outside RunWithTools, got %v", body["tools"])
+	}
+}
@@ -0,0 +1,271 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"maps"
+	"strings"
+	"sync"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// Price is the cost of a model in US dollars per million tokens.
+type Price struct {
+	Input float64 `json:"input" yaml:"input" toml:"input"`
+	// CachedInput applies to prompt tokens served from the provider prompt cache.
+	// Zero means cached tokens are billed as Input.
+	CachedInput float64 `json:"cached_input" yaml:"cached_input" toml:"cached_input"`
+	Output      float64 `json:"output" yaml:"output" toml:"output"`
+}
+
+// defaultPrices holds the list prices of common models. They change over time;
+// use Pricing.Set or NewPricing to correct them.
+var defaultPrices = map[string]Price{
+	"gpt-5":                  {Input: 1.25, CachedInput: 0.125, Output: 10},
+	"gpt-5-mini":             {Input: 0.25, CachedInput: 0.025, Output: 2},
+	"gpt-5-nano":             {Input: 0.05, CachedInput: 0.005, Output: 0.4},
+	"gpt-4.1":                {Input: 2, CachedInput: 0.5, Output: 8},
+	"gpt-4.1-mini":           {Input: 0.4, CachedInput: 0.1, Output: 1.6},
+	"gpt-4.1-nano":           {Input: 0.1, CachedInput: 0.025, Output: 0.4},
+	"gpt-4o":                 {Input: 2.5, CachedInput: 1.25, Output: 10},
+	"gpt-4o-mini":            {Input: 0.15, CachedInput: 0.075, Output: 0.6},
+	"gpt-4-turbo":            {Input: 10, Output: 30},
+	"gpt-4":                  {Input: 30, Output: 60},
+	"gpt-3.5-turbo":          {Input: 0.5, Output: 1.5},
+	"o1":                     {Input: 15, CachedInput: 7.5, Output: 60},
+	"o1-mini":                {Input: 1.1, CachedInput: 0.55, Output: 4.4},
+	"o3":                     {Input: 2, CachedInput: 0.5, Output: 8},
+	"o3-mini":                {Input: 1.1, CachedInput: 0.55, Output: 4.4},
+	"o4-mini":                {Input: 1.1, CachedInput: 0.275, Output: 4.4},
+	"text-embedding-3-small": {Input: 0.02},
+	"text-embedding-3-large": {Input: 0.13},
+	"text-embedding-ada-002": {Input: 0.1},
+	"deepseek-chat":          {Input: 0.28, CachedInput: 0.028, Output: 0.42},
+	"deepseek-reasoner":      {Input: 0.28, CachedInput: 0.028, Output: 0.42},
+	"glm-4-flash":            {},
+	"claude-opus-4-1":        {Input: 15, CachedInput: 1.5, Output: 75},
+	"claude-sonnet-4-5":      {Input: 3, CachedInput: 0.3, Output: 15},
+	"claude-haiku-4-5":       {Input: 1, CachedInput: 0.1, Output: 5},
+	"claude-3-5-haiku":       {Input: 0.8, CachedInput: 0.08, Output: 4},
+	"gemini-2.5-pro":         {Input: 1.25, CachedInput: 0.125, Output: 10},
+	"gemini-2.5-flash":       {Input: 0.3, CachedInput: 0.03, Output: 2.5},
+	"gemini-2.5-flash-lite":  {Input: 0.1, CachedInput: 0.01, Output: 0.4},
+	"gemini-2.0-flash":       {Input: 0.1, CachedInput: 0.025, Output: 0.4},
+	"gemini-embedding-001":   {Input: 0.15},
+}
+
+// Pricing is a registry of model prices. It is safe for concurrent use.
+type Pricing struct {
+	mu     sync.RWMutex
+	prices map[string]Price
+}
+
+// NewPricing returns the default price table with the given prices added or replaced.
+func NewPricing(overrides map[string]Price) *Pricing {
+	p := &Pricing{prices: maps.Clone(defaultPrices)}
+	maps.Copy(p.prices, overrides)
+	return p
+}
+
+// Set adds or replaces the price of a model.
+func (p *Pricing) Set(model string, price Price) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.prices[model] = price
+}
+
+// Lookup returns the price of a model. Models without an exact entry use the
+// longest registered prefix, so dated snapshots such as gpt-4o-2024-08-06 are priced as gpt-4o.
+func (p *Pricing) Lookup(model string) (Price, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if price, ok := p.prices[model]; ok {
+		return price, true
+	}
+	var (
+		best  string
+		found bool
+	)
+	for name := range p.prices {
+		if strings.HasPrefix(model, name) && len(name) > len(best) {
+			best, found = name, true
+		}
+	}
+	return p.prices[best], found
+}
+
+// Cost returns the cost of the usage in US dollars, reporting false when the model has no price.
+func (p *Pricing) Cost(model string, usage openai.Usage) (float64, bool) {
+	price, ok := p.Lookup(model)
+	if !ok {
+		return 0, false
+	}
+	cached := cachedTokens(usage)
+	cachedPrice := price.CachedInput
+	if cachedPrice == 0 {
+		cachedPrice = price.Input
+	}
+	cost := float64(usage.PromptTokens-cached)*price.Input +
+		float64(cached)*cachedPrice +
+		float64(usage.CompletionTokens)*price.Output
+	return cost / 1e6, true
+}
+
+func cachedTokens(usage openai.Usage) int {
+	if usage.PromptTokensDetails == nil {
+		return 0
+	}
+	return usage.PromptTokensDetails.CachedTokens
+}
+
+type usageTagKey struct{}
+
+// WithUsageTag returns a context whose requests are aggregated under the tag
+// by the UsageTracker of the client, e.g. a tenant or feature name.
+func WithUsageTag(ctx context.Context, tag string) context.Context {
+	return context.WithValue(ctx, usageTagKey{}, tag)
+}
+
+func usageTag(ctx context.Context) string {
+	tag, _ := ctx.Value(usageTagKey{}).(string)
+	return tag
+}
+
+// UsageStats aggregates the usage of a group of requests.
+type UsageStats struct {
+	Requests         int64 `json:"requests"`
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CachedTokens     int64 `json:"cached_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+	TotalTokens      int64 `json:"total_tokens"`
+	// Cost is in US dollars.
+	Cost float64 `json:"cost"`
+	// Unpriced counts requests whose model has no price; their cost is missing from Cost.
+	Unpriced int64 `json:"unpriced"`
+}
+
+func (s *UsageStats) add(usage openai.Usage, cost float64, priced bool) {
+	s.Requests++
+	s.PromptTokens += int64(usage.PromptTokens)
+	s.CachedTokens += int64(cachedTokens(usage))
+	s.CompletionTokens += int64(usage.CompletionTokens)
+	s.TotalTokens += int64(usage.TotalTokens)
+	s.Cost += cost
+	if !priced {
+		s.Unpriced++
+	}
+}
+
+// UsageSnapshot is a point-in-time copy of the aggregates of a UsageTracker.
+// Requests without a tag are not listed in ByTag.
+type UsageSnapshot struct {
+	Since      time.Time             `json:"since"`
+	Total      UsageStats            `json:"total"`
+	ByModel    map[string]UsageStats `json:"by_model"`
+	ByProvider map[string]UsageStats `json:"by_provider"`
+	ByTag      map[string]UsageStats `json:"by_tag"`
+}
+
+// UsageTracker aggregates token usage and cost across calls. Attach it to one or more
+// clients with WithUsageTracker. It is safe for concurrent use.
+type UsageTracker struct {
+	pricing *Pricing
+
+	mu         sync.Mutex
+	since      time.Time
+	total      UsageStats
+	byModel    map[string]*UsageStats
+	byProvider map[string]*UsageStats
+	byTag      map[string]*UsageStats
+}
+
+// NewUsageTracker creates a UsageTracker that prices usage with the given registry,
+// or with the default prices when pricing is nil.
+func NewUsageTracker(pricing *Pricing) *UsageTracker {
+	if pricing == nil {
+		pricing = NewPricing(nil)
+	}
+	t := &UsageTracker{pricing: pricing}
+	t.Reset()
+	return t
+}
+
+// Record adds the usage of one request.
+func (t *UsageTracker) Record(provider, model, tag string, usage openai.Usage) {
+	cost, priced := t.pricing.Cost(model, usage)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.total.add(usage, cost, priced)
+	group(t.byModel, model).add(usage, cost, priced)
+	group(t.byProvider, provider).add(usage, cost, priced)
+	if tag != "" {
+		group(t.byTag, tag).add(usage, cost, priced)
+	}
+}
+
+func group(m map[string]*UsageStats, key string) *UsageStats {
+	s, ok := m[key]
+	if !ok {
+		s = &UsageStats{}
+		m[key] = s
+	}
+	return s
+}
+
+// Snapshot returns a copy of the current aggregates.
+func (t *UsageTracker) Snapshot() UsageSnapshot {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	copyGroups := func(m map[string]*UsageStats) map[string]UsageStats {
+		out := make(map[string]UsageStats, len(m))
+		for k, v := range m {
+			out[k] = *v
+		}
+		return out
+	}
+	return UsageSnapshot{
+		Since:      t.since,
+		Total:      t.total,
+		ByModel:    copyGroups(t.byModel),
+		ByProvider: copyGroups(t.byProvider),
+		ByTag:      copyGroups(t.byTag),
+	}
+}
+
+// Reset clears the aggregates.
+func (t *UsageTracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.since = time.Now()
+	t.total = UsageStats{}
+	t.byModel = make(map[string]*UsageStats)
+	t.byProvider = make(map[string]*UsageStats)
+	t.byTag = make(map[string]*UsageStats)
+}
+
+// WriteJSON writes the current snapshot as JSON.
+func (t *UsageTracker) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(t.Snapshot())
+}
+
+// track records the usage of a request made by the client, if a tracker is attached.
+// The model reported by the API is preferred, as it names the exact snapshot.
+func (c *Client) track(ctx context.Context, model string, usage openai.Usage) {
+	if c.usage == nil || usage.TotalTokens == 0 && usage.PromptTokens == 0 {
+		return
+	}
+	if model == "" {
+		model = c.model
+	}
+	c.usage.Record(c.name, model, usageTag(ctx), usage)
+}
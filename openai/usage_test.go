@@ -0,0 +1,151 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	openaisdk "github.com/sashabaranov/go-openai"
+)
+
+func TestPricing_Lookup(t *testing.T) {
+	p := NewPricing(map[string]Price{"my-model": {Input: 1, Output: 2}})
+
+	tests := []struct {
+		model    string
+		expected Price
+		found    bool
+	}{
+		{"gpt-4o", defaultPrices["gpt-4o"], true},
+		{"gpt-4o-mini-2024-07-18", defaultPrices["gpt-4o-mini"], true},
+		{"gpt-4-0613", defaultPrices["gpt-4"], true},
+		{"my-model", Price{Input: 1, Output: 2}, true},
+		{"unknown", Price{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			price, found := p.Lookup(tt.model)
+			if found != tt.found || price != tt.expected {
+				t.Errorf("Expected (%+v, %v), got (%+v, %v)", tt.expected, tt.found, price, found)
+			}
+		})
+	}
+}
+
+func TestPricing_Cost(t *testing.T) {
+	p := NewPricing(map[string]Price{
+		"cached":   {Input: 2, CachedInput: 1, Output: 10},
+		"uncached": {Input: 2, Output: 10},
+	})
+	usage := openaisdk.Usage{
+		PromptTokens:        1000000,
+		CompletionTokens:    100000,
+		PromptTokensDetails: &openaisdk.PromptTokensDetails{CachedTokens: 500000},
+	}
+
+	tests := []struct {
+		model    string
+		expected float64
+	}{
+		// 0.5M input at $2 + 0.5M cached at $1 + 0.1M output at $10
+		{"cached", 1 + 0.5 + 1},
+		// cached tokens fall back to the input price
+		{"uncached", 2 + 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			cost, ok := p.Cost(tt.model, usage)
+			if !ok || math.Abs(cost-tt.expected) > 1e-9 {
+				t.Errorf("Expected cost %f, got %f (%v)", tt.expected, cost, ok)
+			}
+		})
+	}
+}
+
+func TestUsageTracker_Record(t *testing.T) {
+	tracker := NewUsageTracker(NewPricing(map[string]Price{"m": {Input: 1, Output: 2}}))
+
+	usage := openaisdk.Usage{PromptTokens: 1000, CompletionTokens: 500, TotalTokens: 1500}
+	tracker.Record("openai", "m", "tenant-a", usage)
+	tracker.Record("openai", "m", "", usage)
+	tracker.Record("local", "unknown", "tenant-a", usage)
+
+	s := tracker.Snapshot()
+	if s.Total.Requests != 3 || s.Total.TotalTokens != 4500 || s.Total.Unpriced != 1 {
+		t.Errorf("Expected 3 requests, 4500 tokens and 1 unpriced, got %+v", s.Total)
+	}
+
+	if expected := 2 * (1000*1 + 500*2) / 1e6; math.Abs(s.Total.Cost-expected) > 1e-12 {
+		t.Errorf("Expected total cost %f, got %f", expected, s.Total.Cost)
+	}
+
+	if s.ByModel["m"].Requests != 2 || s.ByProvider["local"].Requests != 1 {
+		t.Errorf("Expected grouping by model and provider, got %+v / %+v", s.ByModel, s.ByProvider)
+	}
+
+	if len(s.ByTag) != 1 || s.ByTag["tenant-a"].Requests != 2 {
+		t.Errorf("Expected 2 requests tagged tenant-a, got %+v", s.ByTag)
+	}
+
+	var buf bytes.Buffer
+	if err := tracker.WriteJSON(&buf); err != nil {
+		t.Fatalf("Failed to write JSON: %v", err)
+	}
+	var exported UsageSnapshot
+	if err := json.Unmarshal(buf.Bytes(), &exported); err != nil || exported.Total.Requests != 3 {
+		t.Errorf("Expected JSON export to round-trip, got %+v (%v)", exported.Total, err)
+	}
+
+	tracker.Reset()
+	if s := tracker.Snapshot(); s.Total.Requests != 0 || len(s.ByModel) != 0 {
+		t.Errorf("Expected empty snapshot after reset, got %+v", s)
+	}
+}
+
+func TestClient_WithUsageTracker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"model":"gpt-4o-mini-2024-07-18","choices":[{"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}],"usage":{"prompt_tokens":100,"completion_tokens":20,"total_tokens":120}}`)
+	}))
+	defer server.Close()
+
+	tracker := NewUsageTracker(nil)
+	client, err := New(
+		WithToken("test-token"),
+		WithBaseURL(server.URL),
+		WithName("primary"),
+		WithTemperature(0.1),
+		WithCache(NewMemoryCache(10, 0)),
+		WithUsageTracker(tracker),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	ctx := WithUsageTag(context.Background(), "search")
+	for range 2 {
+		if _, err := client.Completion(ctx, "", "hi"); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+	}
+
+	// The second call is a cache hit and costs nothing.
+	s := tracker.Snapshot()
+	if s.Total.Requests != 1 || s.Total.TotalTokens != 120 {
+		t.Errorf("Expected 1 request of 120 tokens, got %+v", s.Total)
+	}
+
+	if s.ByModel["gpt-4o-mini-2024-07-18"].Requests != 1 || s.ByProvider["primary"].Requests != 1 || s.ByTag["search"].Requests != 1 {
+		t.Errorf("Expected the request under its model, provider and tag, got %+v", s)
+	}
+
+	if s.Total.Unpriced != 0 || s.Total.Cost == 0 {
+		t.Errorf("Expected the request to be priced as gpt-4o-mini, got %+v", s.Total)
+	}
+}